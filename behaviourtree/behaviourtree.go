@@ -0,0 +1,280 @@
+package behaviourtree
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+ A small behaviour tree runtime
+
+ Every tick the tree is walked from the root. Composites decide which of their
+ children get ticked, decorators change the result of their single child and
+ leaves do the actual work. Leaves are not part of this package, whoever builds
+ a tree passes a LeafFactory that knows how to create them (see ecs.NewBehaviourLeafFactory).
+
+ Nodes keep state (e.g. which child of a sequence is still running), so every
+ entity needs its own instance of a tree. Build one per entity from the same NodeConfig.
+
+ Trees are described in JSON:
+
+ {
+   "Type": "Selector",
+   "Children": [
+     {"Type": "Sequence", "Children": [{"Type": "PlayerInRange", "Params": {"Range": 300}}, {"Type": "ChasePlayer"}]},
+     {"Type": "Wait", "Params": {"DurationMs": 500}}
+   ]
+ }
+*/
+
+type Status uint8
+
+const (
+	SUCCESS Status = iota
+	FAILURE
+	RUNNING
+)
+
+// Blackboard is memory shared between all nodes of one tree
+type Blackboard map[string]interface{}
+
+func (b Blackboard) GetFloat(key string) (float64, bool) {
+	value, ok := b[key].(float64)
+	return value, ok
+}
+
+type Context struct {
+	EntityID   uint64
+	ElapsedMs  float64
+	Blackboard Blackboard
+}
+
+type Node interface {
+	Tick(ctx *Context) Status
+	// Reset forgets running state, called when a parent gives up on the node
+	Reset()
+}
+
+type NodeConfig struct {
+	Type     string             `json:"Type"`
+	Children []*NodeConfig      `json:"Children"`
+	Params   map[string]float64 `json:"Params"`
+}
+
+// Param returns the named parameter or the fallback if it was not configured
+func (c *NodeConfig) Param(name string, fallback float64) float64 {
+	if value, ok := c.Params[name]; ok {
+		return value
+	}
+	return fallback
+}
+
+type LeafFactory func(config *NodeConfig) (Node, error)
+
+// Build creates a new tree instance from its description
+func Build(config *NodeConfig, leafFactory LeafFactory) (Node, error) {
+	if config == nil {
+		return nil, errors.New("behaviour tree node without description")
+	}
+
+	children := make([]Node, 0, len(config.Children))
+
+	for _, childConfig := range config.Children {
+		child, err := Build(childConfig, leafFactory)
+
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, child)
+	}
+
+	switch config.Type {
+	case "Sequence":
+		return &Sequence{Children: children}, nil
+	case "Selector":
+		return &Selector{Children: children}, nil
+	}
+
+	if isDecorator(config.Type) {
+		if len(children) != 1 {
+			return nil, fmt.Errorf("decorator %s needs exactly one child, got %d", config.Type, len(children))
+		}
+
+		switch config.Type {
+		case "Inverter":
+			return &Inverter{Child: children[0]}, nil
+		case "Succeeder":
+			return &Succeeder{Child: children[0]}, nil
+		case "Repeater":
+			return &Repeater{Child: children[0], Times: int(config.Param("Times", 0))}, nil
+		case "Cooldown":
+			return &Cooldown{Child: children[0], DurationMs: config.Param("DurationMs", 0)}, nil
+		}
+	}
+
+	return leafFactory(config)
+}
+
+func isDecorator(nodeType string) bool {
+	switch nodeType {
+	case "Inverter", "Succeeder", "Repeater", "Cooldown":
+		return true
+	}
+	return false
+}
+
+// Sequence ticks its children in order until one fails or is still running
+type Sequence struct {
+	Children []Node
+	running  int
+}
+
+func (n *Sequence) Tick(ctx *Context) Status {
+	for ; n.running < len(n.Children); n.running++ {
+		status := n.Children[n.running].Tick(ctx)
+
+		if status == RUNNING {
+			return RUNNING
+		}
+
+		if status == FAILURE {
+			n.Reset()
+			return FAILURE
+		}
+	}
+
+	n.Reset()
+	return SUCCESS
+}
+
+func (n *Sequence) Reset() {
+	for _, child := range n.Children {
+		child.Reset()
+	}
+	n.running = 0
+}
+
+// Selector ticks its children in order until one succeeds or is still running
+type Selector struct {
+	Children []Node
+	running  int
+}
+
+func (n *Selector) Tick(ctx *Context) Status {
+	for i, child := range n.Children {
+		status := child.Tick(ctx)
+
+		if status == FAILURE {
+			continue
+		}
+
+		// A higher priority child took over, the one that was running before is interrupted
+		if i != n.running {
+			n.Children[n.running].Reset()
+		}
+
+		n.running = i
+
+		return status
+	}
+
+	n.Reset()
+	return FAILURE
+}
+
+func (n *Selector) Reset() {
+	for _, child := range n.Children {
+		child.Reset()
+	}
+	n.running = 0
+}
+
+// Inverter turns success into failure and vice versa
+type Inverter struct {
+	Child Node
+}
+
+func (n *Inverter) Tick(ctx *Context) Status {
+	switch n.Child.Tick(ctx) {
+	case SUCCESS:
+		return FAILURE
+	case FAILURE:
+		return SUCCESS
+	}
+	return RUNNING
+}
+
+func (n *Inverter) Reset() {
+	n.Child.Reset()
+}
+
+// Succeeder always succeeds once its child is done
+type Succeeder struct {
+	Child Node
+}
+
+func (n *Succeeder) Tick(ctx *Context) Status {
+	if n.Child.Tick(ctx) == RUNNING {
+		return RUNNING
+	}
+	return SUCCESS
+}
+
+func (n *Succeeder) Reset() {
+	n.Child.Reset()
+}
+
+// Repeater ticks its child Times times, or forever if Times is 0
+type Repeater struct {
+	Child Node
+	Times int
+	count int
+}
+
+func (n *Repeater) Tick(ctx *Context) Status {
+	if n.Child.Tick(ctx) == RUNNING {
+		return RUNNING
+	}
+
+	n.count++
+
+	if n.Times > 0 && n.count >= n.Times {
+		n.count = 0
+		return SUCCESS
+	}
+
+	return RUNNING
+}
+
+func (n *Repeater) Reset() {
+	n.Child.Reset()
+	n.count = 0
+}
+
+// Cooldown fails for DurationMs milliseconds after its child succeeded
+type Cooldown struct {
+	Child      Node
+	DurationMs float64
+	msLeft     float64
+}
+
+func (n *Cooldown) Tick(ctx *Context) Status {
+	if n.msLeft > 0 {
+		n.msLeft -= ctx.ElapsedMs
+		return FAILURE
+	}
+
+	status := n.Child.Tick(ctx)
+
+	if status == SUCCESS {
+		n.msLeft = n.DurationMs
+	}
+
+	return status
+}
+
+func (n *Cooldown) Reset() {
+	// The cooldown keeps running even if the parent gives up on it
+	n.Child.Reset()
+}
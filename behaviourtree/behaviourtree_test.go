@@ -0,0 +1,300 @@
+package behaviourtree
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// stubLeaf returns its statuses one after another and keeps returning the last one
+type stubLeaf struct {
+	statuses []Status
+	ticks    int
+	resets   int
+}
+
+func leaf(statuses ...Status) *stubLeaf {
+	return &stubLeaf{statuses: statuses}
+}
+
+func (n *stubLeaf) Tick(ctx *Context) Status {
+	status := n.statuses[len(n.statuses)-1]
+
+	if n.ticks < len(n.statuses) {
+		status = n.statuses[n.ticks]
+	}
+
+	n.ticks++
+	return status
+}
+
+func (n *stubLeaf) Reset() {
+	n.resets++
+}
+
+func stubLeafFactory(config *NodeConfig) (Node, error) {
+	switch config.Type {
+	case "Succeed":
+		return leaf(SUCCESS), nil
+	case "Fail":
+		return leaf(FAILURE), nil
+	case "Run":
+		return leaf(RUNNING), nil
+	}
+	return nil, fmt.Errorf("unknown behaviour tree node %s", config.Type)
+}
+
+func tick(t *testing.T, node Node, ctx *Context, want Status) {
+	t.Helper()
+
+	if got := node.Tick(ctx); got != want {
+		t.Fatalf("Tick() = %v, want %v", got, want)
+	}
+}
+
+func TestComposites(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(children ...Node) Node
+		children []Status
+		want     Status
+		ticked   []int
+	}{
+		{"sequence all succeed", newSequence, []Status{SUCCESS, SUCCESS}, SUCCESS, []int{1, 1}},
+		{"sequence stops at failure", newSequence, []Status{SUCCESS, FAILURE, SUCCESS}, FAILURE, []int{1, 1, 0}},
+		{"sequence stops at running", newSequence, []Status{SUCCESS, RUNNING, SUCCESS}, RUNNING, []int{1, 1, 0}},
+		{"sequence without children", newSequence, nil, SUCCESS, nil},
+		{"selector stops at success", newSelector, []Status{FAILURE, SUCCESS, FAILURE}, SUCCESS, []int{1, 1, 0}},
+		{"selector all fail", newSelector, []Status{FAILURE, FAILURE}, FAILURE, []int{1, 1}},
+		{"selector stops at running", newSelector, []Status{FAILURE, RUNNING, SUCCESS}, RUNNING, []int{1, 1, 0}},
+		{"selector without children", newSelector, nil, FAILURE, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			leaves := make([]*stubLeaf, len(test.children))
+			children := make([]Node, len(test.children))
+
+			for i, status := range test.children {
+				leaves[i] = leaf(status)
+				children[i] = leaves[i]
+			}
+
+			tick(t, test.build(children...), &Context{}, test.want)
+
+			for i, want := range test.ticked {
+				if leaves[i].ticks != want {
+					t.Errorf("child %d ticked %d times, want %d", i, leaves[i].ticks, want)
+				}
+			}
+		})
+	}
+}
+
+func newSequence(children ...Node) Node {
+	return &Sequence{Children: children}
+}
+
+func newSelector(children ...Node) Node {
+	return &Selector{Children: children}
+}
+
+func TestSequenceResumesRunningChild(t *testing.T) {
+	first, second := leaf(SUCCESS), leaf(RUNNING, SUCCESS)
+	sequence := &Sequence{Children: []Node{first, second}}
+
+	tick(t, sequence, &Context{}, RUNNING)
+	tick(t, sequence, &Context{}, SUCCESS)
+
+	if first.ticks != 1 {
+		t.Errorf("finished child ticked %d times, want 1", first.ticks)
+	}
+
+	// Once done the sequence starts over from the first child
+	tick(t, sequence, &Context{}, SUCCESS)
+
+	if first.ticks != 2 {
+		t.Errorf("first child ticked %d times after restart, want 2", first.ticks)
+	}
+}
+
+func TestSelectorInterruptsLowerPriorityChild(t *testing.T) {
+	higher, lower := leaf(FAILURE, SUCCESS), leaf(RUNNING)
+	selector := &Selector{Children: []Node{higher, lower}}
+
+	tick(t, selector, &Context{}, RUNNING)
+	tick(t, selector, &Context{}, SUCCESS)
+
+	if lower.resets != 1 {
+		t.Errorf("interrupted child reset %d times, want 1", lower.resets)
+	}
+}
+
+func TestDecorators(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(child Node) Node
+		child Status
+		want  Status
+	}{
+		{"inverter success", newInverter, SUCCESS, FAILURE},
+		{"inverter failure", newInverter, FAILURE, SUCCESS},
+		{"inverter running", newInverter, RUNNING, RUNNING},
+		{"succeeder success", newSucceeder, SUCCESS, SUCCESS},
+		{"succeeder failure", newSucceeder, FAILURE, SUCCESS},
+		{"succeeder running", newSucceeder, RUNNING, RUNNING},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tick(t, test.build(leaf(test.child)), &Context{}, test.want)
+		})
+	}
+}
+
+func newInverter(child Node) Node {
+	return &Inverter{Child: child}
+}
+
+func newSucceeder(child Node) Node {
+	return &Succeeder{Child: child}
+}
+
+func TestRepeater(t *testing.T) {
+	child := leaf(SUCCESS, RUNNING, FAILURE)
+	repeater := &Repeater{Child: child, Times: 2}
+
+	tick(t, repeater, &Context{}, RUNNING)
+	// A running child does not count as a repetition
+	tick(t, repeater, &Context{}, RUNNING)
+	tick(t, repeater, &Context{}, SUCCESS)
+
+	forever := &Repeater{Child: leaf(SUCCESS)}
+
+	for i := 0; i < 10; i++ {
+		tick(t, forever, &Context{}, RUNNING)
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	child := leaf(SUCCESS)
+	cooldown := &Cooldown{Child: child, DurationMs: 250}
+	ctx := &Context{ElapsedMs: 100}
+
+	tick(t, cooldown, ctx, SUCCESS)
+
+	for i := 0; i < 3; i++ {
+		tick(t, cooldown, ctx, FAILURE)
+	}
+
+	if child.ticks != 1 {
+		t.Fatalf("child ticked %d times during the cooldown, want 1", child.ticks)
+	}
+
+	// Resetting does not cut the cooldown short
+	tick(t, cooldown, ctx, SUCCESS)
+	cooldown.Reset()
+	tick(t, cooldown, ctx, FAILURE)
+}
+
+func TestCooldownOnlyStartsOnSuccess(t *testing.T) {
+	child := leaf(FAILURE, RUNNING, SUCCESS)
+	cooldown := &Cooldown{Child: child, DurationMs: 250}
+	ctx := &Context{ElapsedMs: 100}
+
+	tick(t, cooldown, ctx, FAILURE)
+	tick(t, cooldown, ctx, RUNNING)
+	tick(t, cooldown, ctx, SUCCESS)
+	tick(t, cooldown, ctx, FAILURE)
+
+	if child.ticks != 3 {
+		t.Errorf("child ticked %d times, want 3", child.ticks)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	description := `{
+		"Type": "Selector",
+		"Children": [
+			{"Type": "Sequence", "Children": [{"Type": "Fail"}, {"Type": "Succeed"}]},
+			{"Type": "Repeater", "Params": {"Times": 3}, "Children": [{"Type": "Succeed"}]},
+			{"Type": "Succeed"}
+		]
+	}`
+
+	var config NodeConfig
+
+	if err := json.Unmarshal([]byte(description), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := Build(&config, stubLeafFactory)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	selector, ok := root.(*Selector)
+
+	if !ok || len(selector.Children) != 3 {
+		t.Fatalf("root is %#v, want a selector with 3 children", root)
+	}
+
+	if repeater, ok := selector.Children[1].(*Repeater); !ok || repeater.Times != 3 {
+		t.Errorf("second child is %#v, want a repeater with Times 3", selector.Children[1])
+	}
+
+	tick(t, root, &Context{}, RUNNING)
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *NodeConfig
+	}{
+		{"missing description", nil},
+		{"unknown node type", &NodeConfig{Type: "Fly"}},
+		{"unknown node type deep down", &NodeConfig{Type: "Sequence", Children: []*NodeConfig{
+			{Type: "Succeed"},
+			{Type: "Inverter", Children: []*NodeConfig{{Type: "Fly"}}},
+		}}},
+		{"decorator without child", &NodeConfig{Type: "Inverter"}},
+		{"decorator with two children", &NodeConfig{Type: "Cooldown", Children: []*NodeConfig{{Type: "Succeed"}, {Type: "Fail"}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if node, err := Build(test.config, stubLeafFactory); err == nil {
+				t.Errorf("Build() = %#v, want an error", node)
+			}
+		})
+	}
+}
+
+func TestNodeConfigParam(t *testing.T) {
+	config := &NodeConfig{Params: map[string]float64{"Range": 120}}
+
+	if got := config.Param("Range", 300); got != 120 {
+		t.Errorf("Param(Range) = %v, want 120", got)
+	}
+
+	if got := config.Param("Speed", 2); got != 2 {
+		t.Errorf("Param(Speed) = %v, want the fallback 2", got)
+	}
+}
+
+func TestBlackboardGetFloat(t *testing.T) {
+	blackboard := Blackboard{"distance": 42.0, "name": "slime"}
+
+	if value, ok := blackboard.GetFloat("distance"); !ok || value != 42 {
+		t.Errorf("GetFloat(distance) = %v, %v, want 42, true", value, ok)
+	}
+
+	if _, ok := blackboard.GetFloat("name"); ok {
+		t.Error("GetFloat(name) found a float in a string")
+	}
+
+	if _, ok := blackboard.GetFloat("missing"); ok {
+		t.Error("GetFloat(missing) found a value")
+	}
+}
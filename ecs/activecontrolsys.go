@@ -1,12 +1,49 @@
 package ecs
 
 import (
+	"math"
+
 	"github.com/t-puetz/GoJumpAndRunAndShoot/input"
 	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+// ActiveControlComponentData holds the movement model of a controllable entity.
+// Accelerations and decelerations are in pixels per tick per tick,
+// speeds are in pixels per tick.
 type ActiveControlComponentData struct {
+	GroundAcceleration float64
+	GroundDeceleration float64
+	AirAcceleration    float64
+	AirDeceleration    float64
+	MaxGroundSpeed     float64
+	MaxAirSpeed        float64
+
+	// Vspeed given at take-off. Releasing the jump key while still rising faster than
+	// JumpReleaseSpeed cuts the jump short, which is what makes the jump height variable.
+	JumpSpeed        float64
+	JumpReleaseSpeed float64
+	// Time windows in milliseconds: jumping is still allowed CoyoteTime after walking off
+	// a ledge, and a jump pressed up to JumpBufferTime before landing is not lost.
+	CoyoteTime     float64
+	JumpBufferTime float64
+
+	// Share of the maximum ground speed left while crouching
+	CrouchSpeedFactor float64
+
+	// nil if the entity cannot shoot
+	Weapon *Weapon
+
+	msSinceGrounded    float64
+	msSinceJumpPressed float64
+	jumpBuffered       bool
+
+	// The key that starts the game on the welcome screen is also the crouch key,
+	// it has to be let go once before the entity crouches
+	crouchKeyReleased bool
+
+	// Sub-pixel horizontal velocity, TransformComponentData.Hspeed only holds whole pixels
+	velocityX float64
 }
 
 type ActiveControlSystem struct {
@@ -34,54 +71,220 @@ func (sys *ActiveControlSystem) Run(delta float64, statemachine *statemachine.St
 		}
 
 		pTCD := sys.ECSManager.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT")
+		pACD := sys.GetComponentData(entityID).(*ActiveControlComponentData)
+		pCCD := sys.ECSManager.GetComponentDataByName(entityID, "COLLIDE_COMPONENT").(*CollisionComponentData)
+		pHCD := sys.ECSManager.GetComponentDataByName(entityID, "HEALTH_COMPONENT").(*HealthComponentData)
 
-		sys.UpdateComponent(delta, pTCD, statemachine)
+		sys.UpdateComponent(delta, pTCD, statemachine, pACD, pCCD, entityID, pHCD)
 	}
 }
 
 func (sys *ActiveControlSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
 	pTCD := essentialData[0].(*TransformComponentData)
 	sm := essentialData[1].(*statemachine.StateMachine)
+	pACD := essentialData[2].(*ActiveControlComponentData)
+	pCCD := essentialData[3].(*CollisionComponentData)
+	entityID := essentialData[4].(uint64)
+	pHCD := essentialData[5].(*HealthComponentData)
 
+	// Only keys pressed while a menu is shown count, a key still held from the game
+	// (e.g. e for interacting with a switch) must not pick an option
 	if sm.CurrentState == statemachine.WELCOME_SCREEN {
-		if sys.Keyboard.KeyHeldDown(sdl.Keycode('s')) {
+		if sys.Keyboard.KeyJustPressed(sdl.Keycode('s')) {
 			sm.DoTransition(statemachine.WELCOME_SCREEN, statemachine.GAME)
 		}
 
-		if sys.Keyboard.KeyHeldDown(sdl.Keycode('o')) {
+		if sys.Keyboard.KeyJustPressed(sdl.Keycode('o')) {
 			sm.DoTransition(statemachine.WELCOME_SCREEN, statemachine.OPTIONS_MENU)
 		}
 
-		if sys.Keyboard.KeyHeldDown(sdl.Keycode('e')) {
+		if sys.Keyboard.KeyJustPressed(sdl.Keycode('e')) {
 			sm.DoTransition(statemachine.WELCOME_SCREEN, statemachine.EXIT)
 		}
 	}
 
+	if sm.CurrentState == statemachine.GAME_OVER {
+		if sys.Keyboard.KeyJustPressed(sdl.Keycode('r')) {
+			sm.DoTransition(statemachine.GAME_OVER, statemachine.GAME)
+		}
+
+		if sys.Keyboard.KeyJustPressed(sdl.Keycode('m')) {
+			sm.DoTransition(statemachine.GAME_OVER, statemachine.WELCOME_SCREEN)
+		}
+
+		if sys.Keyboard.KeyJustPressed(sdl.Keycode('e')) {
+			sm.DoTransition(statemachine.GAME_OVER, statemachine.EXIT)
+		}
+	}
+
 	if sm.CurrentState == statemachine.GAME {
+		var direction float64
+
 		if sys.Keyboard.KeyHeldDown(sdl.Keycode('a')) {
 			pTCD.FlipImg = true
-			pTCD.Hspeed = -5.0
-			pTCD.IsNotMoving = false
+			direction--
 		}
 
 		if sys.Keyboard.KeyHeldDown(sdl.Keycode('d')) {
 			pTCD.FlipImg = false
-			pTCD.Hspeed = 5.0
-			pTCD.IsNotMoving = false
+			direction++
+		}
+
+		if pHCD.IsHurt || pHCD.IsDead {
+			// Knocked back or dead, no control over the entity right now
+			direction = 0
 		}
 
-		if sys.Keyboard.KeyJustPressed(sdl.Keycode(' ')) && !pTCD.IsJumping {
-			pTCD.IsJumping = true
-			pTCD.Vspeed = 31.0
+		sys.crouch(entityID, pTCD, pACD, pCCD)
+
+		if !pTCD.IsCrouching {
+			sys.jump(delta, pTCD, pACD, pCCD)
+		}
+
+		sys.move(delta, direction, pTCD, pACD, pCCD)
+
+		if pACD.Weapon != nil {
+			pACD.Weapon.Reload(delta)
+
+			if sys.Keyboard.KeyHeldDown(sdl.Keycode('f')) {
+				pACD.Weapon.Fire(sys.ECSManager, entityID)
+			}
 		}
 
-		entityStoppedMoving := !sys.Keyboard.KeyHeldDown(sdl.Keycode('d')) && !sys.Keyboard.KeyHeldDown(sdl.Keycode('a'))
+		pTCD.IsNotMoving = pTCD.Hspeed == 0
+	}
+}
+
+// crouch lets an entity with a "Duck" animation crouch while the crouch key is held on the ground.
+// The smaller collider comes with the smaller image, see AnimateSystem. Standing up again needs
+// enough room above the entity, so it stays down as long as it is under a low ceiling.
+func (sys *ActiveControlSystem) crouch(entityID uint64, pTCD *TransformComponentData, pACD *ActiveControlComponentData, pCCD *CollisionComponentData) {
+	standingHeight, canCrouch := sys.ECSManager.standingHeight(entityID)
+
+	if !canCrouch {
+		return
+	}
+
+	crouchKeyHeld := sys.Keyboard.KeyHeldDown(sdl.Keycode('s'))
+
+	if !crouchKeyHeld {
+		pACD.crouchKeyReleased = true
+	}
+
+	crouchKeyHeld = crouchKeyHeld && pACD.crouchKeyReleased
+
+	if crouchKeyHeld && !pTCD.IsCrouching && pCCD.OnGround && !pTCD.IsJumping {
+		pTCD.IsCrouching = true
+		return
+	}
+
+	if crouchKeyHeld || !pTCD.IsCrouching {
+		return
+	}
+
+	rect := sys.ECSManager.GetEntityRect(entityID)
+	headroom := &sdl.Rect{X: rect.X, Y: rect.Y + rect.H - standingHeight, W: rect.W, H: standingHeight - rect.H}
+
+	if headroom.H > 0 && sys.ECSManager.SolidAt(headroom, entityID) {
+		return
+	}
+
+	pTCD.IsCrouching = false
+}
+
+// standingHeight is the height of the entity's "Idle" image. Only entities with both an "Idle"
+// and a "Duck" animation can crouch.
+func (e *ECSManager) standingHeight(entityID uint64) (int32, bool) {
+	pACD := e.GetComponentDataByName(entityID, "ANIMATE_COMPONENT").(*AnimateComponentData)
+	animations := *pACD.AnimationData
+
+	idle, hasIdle := animations["Idle"]
+	_, hasDuck := animations["Duck"]
+
+	if !hasIdle || !hasDuck || len(idle.Images) == 0 {
+		return 0, false
+	}
+
+	_, h := idle.FrameSize(0)
+
+	return h, true
+}
+
+// jump starts a jump when the jump key was pressed recently enough (jump buffer) and the entity
+// stands on the ground or just left it (coyote time). Letting go of the key early cuts the jump.
+func (sys *ActiveControlSystem) jump(delta float64, pTCD *TransformComponentData, pACD *ActiveControlComponentData, pCCD *CollisionComponentData) {
+	elapsedMs := delta * MillisecondsPerTick
+
+	if pCCD.OnGround {
+		pACD.msSinceGrounded = 0
+	} else {
+		pACD.msSinceGrounded += elapsedMs
+	}
+
+	if sys.Keyboard.KeyJustPressed(sdl.Keycode(' ')) {
+		pACD.jumpBuffered = true
+		pACD.msSinceJumpPressed = 0
+	} else if pACD.jumpBuffered {
+		pACD.msSinceJumpPressed += elapsedMs
+		pACD.jumpBuffered = pACD.msSinceJumpPressed <= pACD.JumpBufferTime
+	}
+
+	canJump := !pTCD.IsJumping && (pCCD.OnGround || pACD.msSinceGrounded <= pACD.CoyoteTime)
+
+	if pACD.jumpBuffered && canJump {
+		pACD.jumpBuffered = false
+		pTCD.IsJumping = true
+		pTCD.Vspeed = int32(pACD.JumpSpeed)
+		return
+	}
+
+	jumpReleasedEarly := pTCD.IsJumping && !sys.Keyboard.KeyHeldDown(sdl.Keycode(' '))
+
+	if jumpReleasedEarly && float64(pTCD.Vspeed) > pACD.JumpReleaseSpeed {
+		pTCD.Vspeed = int32(pACD.JumpReleaseSpeed)
+	}
+}
+
+// move accelerates the entity towards its maximum speed in the given direction (-1, 0 or 1)
+// or lets it slow down when no direction is held. On the ground acceleration and deceleration
+// are scaled by the friction of the surface the entity is standing on.
+func (sys *ActiveControlSystem) move(delta float64, direction float64, pTCD *TransformComponentData, pACD *ActiveControlComponentData, pCCD *CollisionComponentData) {
+	acceleration := pACD.AirAcceleration
+	deceleration := pACD.AirDeceleration
+	maxSpeed := pACD.MaxAirSpeed
 
-		if entityStoppedMoving {
-			pTCD.Hspeed = 0
-			pTCD.IsNotMoving = true
-		} else {
-			pTCD.IsNotMoving = false
+	if pCCD.OnGround {
+		acceleration = pACD.GroundAcceleration * pCCD.GroundFriction
+		deceleration = pACD.GroundDeceleration * pCCD.GroundFriction
+		maxSpeed = pACD.MaxGroundSpeed
+	}
+
+	if pTCD.IsCrouching {
+		maxSpeed *= pACD.CrouchSpeedFactor
+	}
+
+	// Hspeed was changed by someone else (e.g. CollideSystem stopped us at a wall)
+	if int32(pACD.velocityX) != pTCD.Hspeed {
+		pACD.velocityX = float64(pTCD.Hspeed)
+	}
+
+	velocityX := pACD.velocityX
+
+	switch {
+	case direction != 0 && velocityX*direction < 0:
+		// Turning around brakes and accelerates at the same time
+		velocityX += direction * (acceleration + deceleration) * delta
+	case direction != 0 && math.Abs(velocityX) < maxSpeed:
+		velocityX = math.Min(math.Abs(velocityX)+acceleration*delta, maxSpeed) * direction
+	case math.Abs(velocityX) > maxSpeed || direction == 0:
+		target := 0.0
+		if direction != 0 {
+			target = maxSpeed
 		}
+		speed := math.Max(math.Abs(velocityX)-deceleration*delta, target)
+		velocityX = math.Copysign(speed, velocityX)
 	}
+
+	pACD.velocityX = velocityX
+	pTCD.Hspeed = int32(velocityX)
 }
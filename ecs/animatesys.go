@@ -12,6 +12,32 @@ type AnimationComponentDataCore struct {
 	Paths                    []string
 	Images                   []*sdl.Surface
 	Textures                 []*sdl.Texture
+	// Only for animations from an atlas, then all Images are the atlas image
+	Frames []*AtlasFrame
+}
+
+// frame is the atlas frame of image i, nil if the animation is not from an atlas
+func (pACDCore *AnimationComponentDataCore) frame(i int) *AtlasFrame {
+	if i >= len(pACDCore.Frames) {
+		return nil
+	}
+	return pACDCore.Frames[i]
+}
+
+// FrameSize is the size of an entity showing image i
+func (pACDCore *AnimationComponentDataCore) FrameSize(i int) (int32, int32) {
+	if frame := pACDCore.frame(i); frame != nil {
+		return frame.SourceW, frame.SourceH
+	}
+	return pACDCore.Images[i].W, pACDCore.Images[i].H
+}
+
+// Show lets the render component show image i of the animation
+func (pACDCore *AnimationComponentDataCore) Show(pRCD *RenderComponentData, i int) {
+	pRCD.Image = pACDCore.Images[i]
+	pRCD.Texture = pACDCore.Textures[i]
+	pRCD.Path = pACDCore.Paths[i]
+	pRCD.Frame = pACDCore.frame(i)
 }
 
 type AnimateComponentData struct {
@@ -54,8 +80,9 @@ func (sys *AnimateSystem) Run(delta float64, statemachine *statemachine.StateMac
 
 		animationName := ""
 
-		if sys.ECSManager.HasNamedComponent(components, "ACTIVE_CONTROL_COMPONENT") {
-			// Check for Player
+		if sys.ECSManager.HasNamedComponent(components, "ACTIVE_CONTROL_COMPONENT") || sys.ECSManager.HasNamedComponent(components, "PASSIVE_CONTROL_COMPONENT_NPC") ||
+			sys.ECSManager.HasNamedComponent(components, "BEHAVIOUR_COMPONENT") {
+			// Player and NPCs are animated according to how they move
 
 			if pTCD.IsNotMoving {
 				animationName = "Idle"
@@ -66,14 +93,72 @@ func (sys *AnimateSystem) Run(delta float64, statemachine *statemachine.StateMac
 			if pTCD.IsJumping {
 				animationName = "Jump"
 			}
+
+			if pTCD.IsCrouching {
+				animationName = "Duck"
+			}
+		}
+
+		if sys.ECSManager.HasNamedComponent(components, "CHECKPOINT_COMPONENT") {
+			if sys.ECSManager.GetComponentDataByName(entityID, "CHECKPOINT_COMPONENT").(*CheckpointComponentData).Activated {
+				animationName = "Active"
+			} else {
+				animationName = "Inactive"
+			}
+		}
+
+		if sys.ECSManager.HasNamedComponent(components, "SWITCH_COMPONENT") {
+			if sys.ECSManager.GetComponentDataByName(entityID, "SWITCH_COMPONENT").(*SwitchComponentData).On {
+				animationName = "On"
+			} else {
+				animationName = "Off"
+			}
+		}
+
+		if sys.ECSManager.HasNamedComponent(components, "DOOR_COMPONENT") {
+			if sys.ECSManager.IsOpenDoor(entityID, components) {
+				animationName = "Open"
+			} else {
+				animationName = "Closed"
+			}
+		}
+
+		if sys.ECSManager.HasNamedComponent(components, "BREAKABLE_COMPONENT") {
+			if _, hasBreakAnimation := animationTypeMap["Break"]; hasBreakAnimation && sys.ECSManager.IsPassable(entityID, components) {
+				animationName = "Break"
+			}
+		}
+
+		if sys.ECSManager.HasNamedComponent(components, "HEALTH_COMPONENT") {
+			pHCD := sys.ECSManager.GetComponentDataByName(entityID, "HEALTH_COMPONENT").(*HealthComponentData)
+
+			if _, hasHurtAnimation := animationTypeMap["Hurt"]; pHCD.IsHurt && hasHurtAnimation {
+				animationName = "Hurt"
+			}
+		}
+
+		animationName = chooseAvailableAnimation(animationTypeMap, animationName, "Idle", "Walk")
+
+		if animationName == "" {
+			continue
 		}
 
 		pACDCore := animationTypeMap[animationName]
 
-		sys.UpdateComponent(delta, pRCD, pACD, pACDCore, animationName)
+		sys.UpdateComponent(delta, pRCD, pACD, pACDCore, animationName, pTCD)
 	}
 }
 
+// chooseAvailableAnimation returns the first of the given animation names the entity has an animation for
+func chooseAvailableAnimation(animationTypeMap map[string]*AnimationComponentDataCore, animationNames ...string) string {
+	for _, animationName := range animationNames {
+		if _, ok := animationTypeMap[animationName]; ok {
+			return animationName
+		}
+	}
+	return ""
+}
+
 func (sys *AnimateSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
 	pRCD := essentialData[0].(*RenderComponentData)
 	pACD := essentialData[1].(*AnimateComponentData)
@@ -84,11 +169,17 @@ func (sys *AnimateSystem) UpdateComponent(delta float64, essentialData ...interf
 	animationName := essentialData[3].(string)
 
 	if pACD.LastAnimation != animationName {
+		// Ducking changes the height a lot, keep the feet where they are instead of the head
+		if pRCD.Image != nil && (pACD.LastAnimation == "Duck" || animationName == "Duck") {
+			pTCD := essentialData[4].(*TransformComponentData)
+			_, oldH := pRCD.Size()
+			_, newH := pACDCore.FrameSize(0)
+			pTCD.PosY += oldH - newH
+		}
+
 		pACD.LastAnimation = animationName
 		pACDCore.CurrentFrame = 0
-		pRCD.Image = pACDCore.Images[0]
-		pRCD.Texture = pACDCore.Textures[0]
-		pRCD.Path = pACDCore.Paths[0]
+		pACDCore.Show(pRCD, 0)
 		return
 	}
 
@@ -96,9 +187,7 @@ func (sys *AnimateSystem) UpdateComponent(delta float64, essentialData ...interf
 	moreThanOneImage := pACDCore.NumberAnimations > 1
 
 	if ! moreThanOneImage {
-		pRCD.Image = pACDCore.Images[0]
-		pRCD.Texture = pACDCore.Textures[0]
-		pRCD.Path = pACDCore.Paths[0]
+		pACDCore.Show(pRCD, 0)
 	}
 
 	if ! timeForNextImage || ! moreThanOneImage {
@@ -110,16 +199,15 @@ func (sys *AnimateSystem) UpdateComponent(delta float64, essentialData ...interf
 	var nextIndex int
 
 	for i, image := range pACDCore.Images {
-		if pRCD.Image == image {
+		// The frames of an atlas animation share one image
+		if pRCD.Image == image && pRCD.Frame == pACDCore.frame(i) {
 			if i < len(pACDCore.Images)-1 {
 				nextIndex = i + 1
 			} else if i == len(pACDCore.Images)-1 {
 				nextIndex = 0
 			}
 
-			pRCD.Image = pACDCore.Images[nextIndex]
-			pRCD.Texture = pACDCore.Textures[nextIndex]
-			pRCD.Path = pACDCore.Paths[nextIndex]
+			pACDCore.Show(pRCD, nextIndex)
 			break
 		}
 	}
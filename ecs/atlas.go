@@ -0,0 +1,144 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// AtlasFrame is one sprite inside the image of an Atlas
+type AtlasFrame struct {
+	Name string
+	// Where the sprite is inside the atlas image
+	Src sdl.Rect
+	// Where the (maybe trimmed) sprite is inside the untrimmed sprite
+	OffsetX int32
+	OffsetY int32
+	// Size of the untrimmed sprite, this is the size of the entity showing the frame
+	SourceW int32
+	SourceH int32
+}
+
+// Atlas describes the sprites packed into one image, as exported by TexturePacker
+type Atlas struct {
+	// Path of the atlas image, relative to the working directory like all image paths
+	ImagePath string
+	// Sorted by name, TexturePacker names the frames of an animation in order
+	Frames []*AtlasFrame
+}
+
+type atlasJSONRect struct {
+	X int32 `json:"x"`
+	Y int32 `json:"y"`
+	W int32 `json:"w"`
+	H int32 `json:"h"`
+}
+
+type atlasJSONFrame struct {
+	Frame            atlasJSONRect `json:"frame"`
+	Rotated          bool          `json:"rotated"`
+	Trimmed          bool          `json:"trimmed"`
+	SpriteSourceSize atlasJSONRect `json:"spriteSourceSize"`
+	SourceSize       atlasJSONRect `json:"sourceSize"`
+}
+
+type atlasJSON struct {
+	Frames map[string]atlasJSONFrame `json:"frames"`
+	Meta   struct {
+		Image string `json:"image"`
+	} `json:"meta"`
+}
+
+// LoadAtlas reads a TexturePacker JSON hash file. Flash exports these as UTF-16, so any
+// of UTF-8 and UTF-16 with byte order mark are accepted.
+func LoadAtlas(path string) (*Atlas, error) {
+	raw, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := decodeText(raw)
+
+	if err != nil {
+		return nil, fmt.Errorf("atlas %s: %s", path, err)
+	}
+
+	var description atlasJSON
+
+	if err := json.Unmarshal(content, &description); err != nil {
+		return nil, fmt.Errorf("atlas %s: %s", path, err)
+	}
+
+	if description.Meta.Image == "" {
+		return nil, fmt.Errorf("atlas %s names no image", path)
+	}
+
+	atlas := &Atlas{
+		ImagePath: filepath.Join(filepath.Dir(path), description.Meta.Image),
+		Frames:    make([]*AtlasFrame, 0, len(description.Frames)),
+	}
+
+	for name, frame := range description.Frames {
+		if frame.Rotated {
+			return nil, fmt.Errorf("atlas %s: frame %s is rotated, which is not supported", path, name)
+		}
+
+		atlasFrame := &AtlasFrame{
+			Name:    name,
+			Src:     sdl.Rect{X: frame.Frame.X, Y: frame.Frame.Y, W: frame.Frame.W, H: frame.Frame.H},
+			SourceW: frame.Frame.W,
+			SourceH: frame.Frame.H,
+		}
+
+		if frame.Trimmed {
+			atlasFrame.OffsetX = frame.SpriteSourceSize.X
+			atlasFrame.OffsetY = frame.SpriteSourceSize.Y
+			atlasFrame.SourceW = frame.SourceSize.W
+			atlasFrame.SourceH = frame.SourceSize.H
+		}
+
+		atlas.Frames = append(atlas.Frames, atlasFrame)
+	}
+
+	sort.Slice(atlas.Frames, func(i, j int) bool {
+		return atlas.Frames[i].Name < atlas.Frames[j].Name
+	})
+
+	return atlas, nil
+}
+
+// decodeText turns UTF-16 text with byte order mark into UTF-8, other text is returned as it is
+func decodeText(raw []byte) ([]byte, error) {
+	littleEndian := bytes.HasPrefix(raw, []byte{0xFF, 0xFE})
+	bigEndian := bytes.HasPrefix(raw, []byte{0xFE, 0xFF})
+
+	if !littleEndian && !bigEndian {
+		return bytes.TrimPrefix(raw, []byte{0xEF, 0xBB, 0xBF}), nil
+	}
+
+	raw = raw[2:]
+
+	if len(raw)%2 != 0 {
+		return nil, errors.New("UTF-16 text of odd length")
+	}
+
+	units := make([]uint16, len(raw)/2)
+
+	for i := range units {
+		if littleEndian {
+			units[i] = uint16(raw[2*i]) | uint16(raw[2*i+1])<<8
+		} else {
+			units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+		}
+	}
+
+	return []byte(string(utf16.Decode(units))), nil
+}
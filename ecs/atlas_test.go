@@ -0,0 +1,140 @@
+package ecs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const testAtlas = "../assets/Player/p1_walk/p1_walk.json"
+
+func TestLoadAtlas(t *testing.T) {
+	atlas, err := LoadAtlas(testAtlas)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filepath.Join("..", "assets", "Player", "p1_walk", "p1_walk.png"); atlas.ImagePath != want {
+		t.Errorf("ImagePath = %s, want %s", atlas.ImagePath, want)
+	}
+
+	if len(atlas.Frames) != 11 {
+		t.Fatalf("%d frames, want 11", len(atlas.Frames))
+	}
+
+	for i, frame := range atlas.Frames {
+		if want := fmt.Sprintf("Symbol 2 instance %d", 10000+i); frame.Name != want {
+			t.Errorf("frame %d is %s, want %s", i, frame.Name, want)
+		}
+
+		if frame.SourceW != 73 || frame.SourceH != 97 {
+			t.Errorf("frame %s is %dx%d untrimmed, want 73x97", frame.Name, frame.SourceW, frame.SourceH)
+		}
+	}
+
+	first := atlas.Frames[0]
+
+	if want := (sdl.Rect{X: 0, Y: 0, W: 67, H: 92}); first.Src != want {
+		t.Errorf("first frame is at %v in the image, want %v", first.Src, want)
+	}
+
+	if first.OffsetX != 4 || first.OffsetY != 5 {
+		t.Errorf("first frame is trimmed by (%d, %d), want (4, 5)", first.OffsetX, first.OffsetY)
+	}
+}
+
+// writeAtlas saves an atlas description with a single frame and returns its path
+func writeAtlas(t *testing.T, frame string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "atlas.json")
+	content := fmt.Sprintf(`{"frames": {"walk": %s}, "meta": {"image": "atlas.png"}}`, frame)
+
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadAtlasUntrimmedFrame(t *testing.T) {
+	atlas, err := LoadAtlas(writeAtlas(t, `{"frame": {"x": 10, "y": 20, "w": 30, "h": 40}, "trimmed": false,
+		"spriteSourceSize": {"x": 0, "y": 0, "w": 30, "h": 40}, "sourceSize": {"w": 30, "h": 40}}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := AtlasFrame{Name: "walk", Src: sdl.Rect{X: 10, Y: 20, W: 30, H: 40}, SourceW: 30, SourceH: 40}
+
+	if got := *atlas.Frames[0]; got != want {
+		t.Errorf("frame = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadAtlasErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path func(t *testing.T) string
+	}{
+		{"rotated frame", func(t *testing.T) string {
+			return writeAtlas(t, `{"frame": {"x": 0, "y": 0, "w": 30, "h": 40}, "rotated": true}`)
+		}},
+		{"no image", func(t *testing.T) string {
+			path := filepath.Join(t.TempDir(), "atlas.json")
+			_ = ioutil.WriteFile(path, []byte(`{"frames": {}}`), 0644)
+			return path
+		}},
+		{"no JSON", func(t *testing.T) string {
+			path := filepath.Join(t.TempDir(), "atlas.json")
+			_ = ioutil.WriteFile(path, []byte("frames"), 0644)
+			return path
+		}},
+		{"missing file", func(t *testing.T) string {
+			return filepath.Join(t.TempDir(), "missing.json")
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if atlas, err := LoadAtlas(test.path(t)); err == nil {
+				t.Errorf("LoadAtlas() = %+v, want an error", atlas)
+			}
+		})
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []byte
+		want string
+	}{
+		{"UTF-8", []byte("{\"ä\"}"), "{\"ä\"}"},
+		{"UTF-8 with byte order mark", []byte("\xEF\xBB\xBF{}"), "{}"},
+		{"UTF-16 little endian", []byte{0xFF, 0xFE, '{', 0, 0xE4, 0, '}', 0}, "{ä}"},
+		{"UTF-16 big endian", []byte{0xFE, 0xFF, 0, '{', 0, 0xE4, 0, '}'}, "{ä}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := decodeText(test.raw)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != test.want {
+				t.Errorf("decodeText() = %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	if _, err := decodeText([]byte{0xFF, 0xFE, '{'}); err == nil {
+		t.Error("decodeText() of UTF-16 with odd length did not fail")
+	}
+}
@@ -0,0 +1,226 @@
+package ecs
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/behaviourtree"
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+// Blackboard keys the BehaviourSystem fills in before every tick
+const (
+	BLACKBOARD_PLAYER_ID          = "PlayerID"
+	BLACKBOARD_PLAYER_X           = "PlayerX"
+	BLACKBOARD_PLAYER_Y           = "PlayerY"
+	BLACKBOARD_DISTANCE_TO_PLAYER = "DistanceToPlayer"
+)
+
+type BehaviourComponentData struct {
+	Tree       behaviourtree.Node
+	Blackboard behaviourtree.Blackboard
+	// nil if the entity cannot shoot
+	Weapon *Weapon
+}
+
+type BehaviourSystem struct {
+	*CommonSystemData
+}
+
+func NewBehaviourSystem(e *ECSManager) *BehaviourSystem {
+	return &BehaviourSystem{
+		CommonSystemData: NewCommonSystemData("BEHAVIOUR_COMPONENT", e),
+	}
+}
+
+func (sys *BehaviourSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	playerID, hasPlayer := ecsManager.FindPlayer()
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) {
+			continue
+		}
+
+		pBCD := sys.GetComponentData(entityID).(*BehaviourComponentData)
+		pHCD := ecsManager.GetComponentDataByName(entityID, "HEALTH_COMPONENT").(*HealthComponentData)
+
+		if pBCD.Tree == nil || pHCD.IsHurt || pHCD.IsDead {
+			continue
+		}
+
+		if pBCD.Weapon != nil {
+			pBCD.Weapon.Reload(delta)
+		}
+
+		delete(pBCD.Blackboard, BLACKBOARD_PLAYER_ID)
+
+		if hasPlayer {
+			entityRect := ecsManager.GetEntityRect(entityID)
+			playerRect := ecsManager.GetEntityRect(playerID)
+
+			playerX := float64(playerRect.X + playerRect.W/2)
+			playerY := float64(playerRect.Y + playerRect.H/2)
+
+			pBCD.Blackboard[BLACKBOARD_PLAYER_ID] = playerID
+			pBCD.Blackboard[BLACKBOARD_PLAYER_X] = playerX
+			pBCD.Blackboard[BLACKBOARD_PLAYER_Y] = playerY
+			pBCD.Blackboard[BLACKBOARD_DISTANCE_TO_PLAYER] = math.Hypot(playerX-float64(entityRect.X+entityRect.W/2), playerY-float64(entityRect.Y+entityRect.H/2))
+		}
+
+		sys.UpdateComponent(delta, entityID, pBCD)
+	}
+}
+
+func (sys *BehaviourSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	entityID := essentialData[0].(uint64)
+	pBCD := essentialData[1].(*BehaviourComponentData)
+
+	pBCD.Tree.Tick(&behaviourtree.Context{
+		EntityID:   entityID,
+		ElapsedMs:  delta * MillisecondsPerTick,
+		Blackboard: pBCD.Blackboard,
+	})
+
+	pTCD := sys.ECSManager.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+	pTCD.IsNotMoving = pTCD.Hspeed == 0
+}
+
+// FindPlayer returns the first entity that is controlled by the keyboard
+func (e *ECSManager) FindPlayer() (uint64, bool) {
+	for el := e.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		components := el.Value.([]uint16)
+
+		if e.HasNamedComponent(components, "ACTIVE_CONTROL_COMPONENT") && e.HasNamedComponent(components, "TRANSFORM_COMPONENT") {
+			return el.Key.(uint64), true
+		}
+	}
+	return 0, false
+}
+
+// Leaves
+
+// NewBehaviourLeafFactory creates the leaf nodes behaviour trees can use:
+//
+//	PlayerInRange (Range)  succeeds if the player is at most Range pixels away
+//	ChasePlayer (Speed)    walks towards the player
+//	FleePlayer (Speed)     walks away from the player
+//	FacePlayer             turns towards the player
+//	Shoot                  fires the entity's weapon, fails if it is not ready
+//	Wait (DurationMs)      stands still and is running until the time is over
+//	Stop                   stands still
+func NewBehaviourLeafFactory(e *ECSManager) behaviourtree.LeafFactory {
+	return func(config *behaviourtree.NodeConfig) (behaviourtree.Node, error) {
+		switch config.Type {
+		case "PlayerInRange":
+			return &playerInRangeLeaf{Range: config.Param("Range", 300)}, nil
+		case "ChasePlayer":
+			return &walkLeaf{ecsManager: e, Speed: int32(config.Param("Speed", 2)), Towards: true}, nil
+		case "FleePlayer":
+			return &walkLeaf{ecsManager: e, Speed: int32(config.Param("Speed", 2)), Towards: false}, nil
+		case "FacePlayer":
+			return &walkLeaf{ecsManager: e, Speed: 0, Towards: true}, nil
+		case "Shoot":
+			return &shootLeaf{ecsManager: e}, nil
+		case "Wait":
+			return &waitLeaf{ecsManager: e, DurationMs: config.Param("DurationMs", 1000)}, nil
+		case "Stop":
+			return &waitLeaf{ecsManager: e, DurationMs: 0}, nil
+		}
+		return nil, fmt.Errorf("unknown behaviour tree node %s", config.Type)
+	}
+}
+
+type playerInRangeLeaf struct {
+	Range float64
+}
+
+func (n *playerInRangeLeaf) Tick(ctx *behaviourtree.Context) behaviourtree.Status {
+	distance, ok := ctx.Blackboard.GetFloat(BLACKBOARD_DISTANCE_TO_PLAYER)
+
+	if _, hasPlayer := ctx.Blackboard[BLACKBOARD_PLAYER_ID]; !hasPlayer || !ok || distance > n.Range {
+		return behaviourtree.FAILURE
+	}
+	return behaviourtree.SUCCESS
+}
+
+func (n *playerInRangeLeaf) Reset() {}
+
+// walkLeaf walks towards or away from the player, with a speed of 0 it only turns
+type walkLeaf struct {
+	ecsManager *ECSManager
+	Speed      int32
+	Towards    bool
+}
+
+func (n *walkLeaf) Tick(ctx *behaviourtree.Context) behaviourtree.Status {
+	playerX, ok := ctx.Blackboard.GetFloat(BLACKBOARD_PLAYER_X)
+
+	if !ok {
+		return behaviourtree.FAILURE
+	}
+
+	pTCD := n.ecsManager.GetComponentDataByName(ctx.EntityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+	entityRect := n.ecsManager.GetEntityRect(ctx.EntityID)
+
+	direction := int32(1)
+
+	if playerX < float64(entityRect.X+entityRect.W/2) {
+		direction = -1
+	}
+
+	if !n.Towards {
+		direction *= -1
+	}
+
+	pTCD.Hspeed = direction * n.Speed
+	pTCD.FlipImg = direction < 0
+
+	return behaviourtree.SUCCESS
+}
+
+func (n *walkLeaf) Reset() {}
+
+type shootLeaf struct {
+	ecsManager *ECSManager
+}
+
+func (n *shootLeaf) Tick(ctx *behaviourtree.Context) behaviourtree.Status {
+	pBCD := n.ecsManager.GetComponentDataByName(ctx.EntityID, "BEHAVIOUR_COMPONENT").(*BehaviourComponentData)
+
+	if pBCD.Weapon == nil || !pBCD.Weapon.Fire(n.ecsManager, ctx.EntityID) {
+		return behaviourtree.FAILURE
+	}
+	return behaviourtree.SUCCESS
+}
+
+func (n *shootLeaf) Reset() {}
+
+type waitLeaf struct {
+	ecsManager *ECSManager
+	DurationMs float64
+	msWaited   float64
+}
+
+func (n *waitLeaf) Tick(ctx *behaviourtree.Context) behaviourtree.Status {
+	pTCD := n.ecsManager.GetComponentDataByName(ctx.EntityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+	pTCD.Hspeed = 0
+
+	n.msWaited += ctx.ElapsedMs
+
+	if n.msWaited < n.DurationMs {
+		return behaviourtree.RUNNING
+	}
+
+	n.Reset()
+	return behaviourtree.SUCCESS
+}
+
+func (n *waitLeaf) Reset() {
+	n.msWaited = 0
+}
@@ -0,0 +1,147 @@
+package ecs
+
+import (
+	"math/rand"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+// LootDrop is one entry of a loot table. The chance of a drop is its Weight
+// divided by the sum of all weights of the table, an empty Prefab drops nothing.
+type LootDrop struct {
+	Prefab string
+	Weight int
+}
+
+type BreakableComponentData struct {
+	HP int32
+	// Name of the loot table in ECSManager.LootTables, empty if nothing drops
+	LootTable string
+	Broken    bool
+	// Left of the "Break" animation before the entity is removed
+	breakTimeLeft float64
+}
+
+// BreakableSystem removes broken tiles and boxes once their "Break" animation is over.
+// Breaking them is done by CollideSystem (hit from below or shot), see DamageBreakable.
+type BreakableSystem struct {
+	*CommonSystemData
+}
+
+func NewBreakableSystem(e *ECSManager) *BreakableSystem {
+	return &BreakableSystem{
+		CommonSystemData: NewCommonSystemData("BREAKABLE_COMPONENT", e),
+	}
+}
+
+func (sys *BreakableSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) || ecsManager.IsMarkedForRemoval(entityID) {
+			continue
+		}
+
+		pBRCD := sys.GetComponentData(entityID).(*BreakableComponentData)
+
+		if !pBRCD.Broken {
+			continue
+		}
+
+		sys.UpdateComponent(delta, entityID, pBRCD)
+	}
+}
+
+func (sys *BreakableSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	entityID := essentialData[0].(uint64)
+	pBRCD := essentialData[1].(*BreakableComponentData)
+
+	pBRCD.breakTimeLeft -= delta * MillisecondsPerTick
+
+	if pBRCD.breakTimeLeft <= 0 {
+		sys.ECSManager.MarkEntityForRemoval(entityID)
+	}
+}
+
+// DamageBreakable takes hit points from a breakable entity. When none are left the entity
+// breaks and drops its loot. It returns true if the entity broke.
+func (e *ECSManager) DamageBreakable(target uint64, damage int32) bool {
+	components, ok := e.EntityToComponentMap.Get(target)
+
+	if !ok || !e.HasNamedComponent(components.([]uint16), "BREAKABLE_COMPONENT") {
+		return false
+	}
+
+	pBRCD := e.GetComponentDataByName(target, "BREAKABLE_COMPONENT").(*BreakableComponentData)
+
+	if pBRCD.Broken {
+		return false
+	}
+
+	pBRCD.HP -= damage
+
+	if pBRCD.HP > 0 {
+		return false
+	}
+
+	pBRCD.Broken = true
+	pBRCD.breakTimeLeft = e.breakAnimationTime(target)
+	e.dropLoot(target, pBRCD.LootTable)
+
+	return true
+}
+
+// breakAnimationTime is how long the entity's "Break" animation runs once, 0 if it has none
+func (e *ECSManager) breakAnimationTime(entityID uint64) float64 {
+	pACD := e.GetComponentDataByName(entityID, "ANIMATE_COMPONENT").(*AnimateComponentData)
+	breakAnimation, ok := (*pACD.AnimationData)["Break"]
+
+	if !ok {
+		return 0
+	}
+
+	return float64(breakAnimation.NumberAnimations) * float64(breakAnimation.DefaultAnimationDuration) * MillisecondsPerTick
+}
+
+// dropLoot spawns a randomly chosen item of the loot table where the entity is
+func (e *ECSManager) dropLoot(entityID uint64, lootTable string) {
+	drops, ok := e.LootTables[lootTable]
+
+	if !ok {
+		return
+	}
+
+	totalWeight := 0
+
+	for _, drop := range drops {
+		totalWeight += drop.Weight
+	}
+
+	if totalWeight <= 0 {
+		return
+	}
+
+	roll := rand.Intn(totalWeight)
+
+	for _, drop := range drops {
+		if roll >= drop.Weight {
+			roll -= drop.Weight
+			continue
+		}
+
+		prefab, ok := e.Prefabs[drop.Prefab]
+
+		if !ok {
+			// Nothing dropped
+			return
+		}
+
+		rect := e.GetEntityRect(entityID)
+		e.SpawnPrefab(drop.Prefab, rect.X+rect.W/2-prefab.Image.W/2, rect.Y+rect.H/2-prefab.Image.H/2)
+		return
+	}
+}
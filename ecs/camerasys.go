@@ -0,0 +1,168 @@
+package ecs
+
+import (
+	"math"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+// CameraComponentData describes which part of the level is shown. The position of the view's
+// top left corner in world coordinates is the camera entity's TransformComponentData.
+type CameraComponentData struct {
+	// Entity the camera follows
+	Target uint64
+	// The target can move inside the dead zone, which is centered in the view,
+	// without the camera following it
+	DeadZoneWidth  int32
+	DeadZoneHeight int32
+	// How far the camera looks ahead in the direction the target is facing
+	LookAhead float64
+	// Share of the way to its destination the camera moves per tick, 0 snaps right to it
+	Smoothing  float64
+	ViewWidth  int32
+	ViewHeight int32
+
+	// Sub-pixel position, TransformComponentData.PosX and PosY only hold whole pixels
+	posX float64
+	posY float64
+}
+
+// CameraSystem moves the cameras after their targets. Entities stay in world coordinates,
+// the RenderSystem subtracts the camera position when drawing.
+type CameraSystem struct {
+	*CommonSystemData
+}
+
+func NewCameraSystem(e *ECSManager) *CameraSystem {
+	return &CameraSystem{
+		CommonSystemData: NewCommonSystemData("CAMERA_COMPONENT", e),
+	}
+}
+
+func (sys *CameraSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) {
+			continue
+		}
+
+		pCAMD := sys.GetComponentData(entityID).(*CameraComponentData)
+		pTCD := ecsManager.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+
+		sys.UpdateComponent(delta, pCAMD, pTCD)
+	}
+}
+
+func (sys *CameraSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	pCAMD := essentialData[0].(*CameraComponentData)
+	pTCD := essentialData[1].(*TransformComponentData)
+
+	progress := 1.0
+
+	if pCAMD.Smoothing > 0 {
+		progress = math.Min(pCAMD.Smoothing*delta, 1.0)
+	}
+
+	sys.ECSManager.moveCamera(pCAMD, pTCD, progress)
+}
+
+// moveCamera moves the camera the given share (0 to 1) of the way to where it should be
+func (e *ECSManager) moveCamera(pCAMD *CameraComponentData, pTCD *TransformComponentData, progress float64) {
+	if _, targetExists := e.EntityToComponentMap.Get(pCAMD.Target); !targetExists {
+		return
+	}
+
+	targetRect := e.GetEntityRect(pCAMD.Target)
+	pTCDTarget := e.GetComponentDataByName(pCAMD.Target, "TRANSFORM_COMPONENT").(*TransformComponentData)
+
+	lookAhead := pCAMD.LookAhead
+
+	if pTCDTarget.FlipImg {
+		lookAhead = -lookAhead
+	}
+
+	focusX := float64(targetRect.X+targetRect.W/2) + lookAhead
+	focusY := float64(targetRect.Y + targetRect.H/2)
+
+	destinationX := followInDeadZone(pCAMD.posX, focusX, pCAMD.ViewWidth, pCAMD.DeadZoneWidth)
+	destinationY := followInDeadZone(pCAMD.posY, focusY, pCAMD.ViewHeight, pCAMD.DeadZoneHeight)
+
+	posX := pCAMD.posX + (destinationX-pCAMD.posX)*progress
+	posY := pCAMD.posY + (destinationY-pCAMD.posY)*progress
+
+	pCAMD.posX, pCAMD.posY = e.clampToLevelBounds(posX, posY, pCAMD.ViewWidth, pCAMD.ViewHeight)
+
+	pTCD.LastPosX = pTCD.PosX
+	pTCD.LastPosY = pTCD.PosY
+	pTCD.PosX = int32(math.Round(pCAMD.posX))
+	pTCD.PosY = int32(math.Round(pCAMD.posY))
+}
+
+// followInDeadZone returns the view position along one axis that keeps focus inside the dead zone
+func followInDeadZone(viewPos, focus float64, viewSize, deadZoneSize int32) float64 {
+	center := viewPos + float64(viewSize)/2
+	halfDeadZone := float64(deadZoneSize) / 2
+
+	switch {
+	case focus > center+halfDeadZone:
+		center = focus - halfDeadZone
+	case focus < center-halfDeadZone:
+		center = focus + halfDeadZone
+	}
+
+	return center - float64(viewSize)/2
+}
+
+// clampToLevelBounds keeps a view of the given size inside the level.
+// Levels smaller than the view are shown from their top left corner.
+func (e *ECSManager) clampToLevelBounds(posX, posY float64, width, height int32) (float64, float64) {
+	bounds := e.LevelBounds
+
+	if bounds == nil {
+		return posX, posY
+	}
+
+	posX = math.Max(float64(bounds.MinX), math.Min(posX, float64(bounds.MaxX-width)))
+	posY = math.Max(float64(bounds.MinY), math.Min(posY, float64(bounds.MaxY-height)))
+
+	return posX, posY
+}
+
+// SnapCameras moves all cameras right to their targets, e.g. after the player respawned
+func (e *ECSManager) SnapCameras() {
+	for el := e.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !e.HasNamedComponent(components, "CAMERA_COMPONENT") {
+			continue
+		}
+
+		pCAMD := e.GetComponentDataByName(entityID, "CAMERA_COMPONENT").(*CameraComponentData)
+		pTCD := e.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+
+		e.moveCamera(pCAMD, pTCD, 1.0)
+	}
+}
+
+// CameraPosition is the world position of the top left corner of the screen, (0, 0) without a camera
+func (e *ECSManager) CameraPosition() (int32, int32) {
+	for el := e.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !e.HasNamedComponent(components, "CAMERA_COMPONENT") {
+			continue
+		}
+
+		pTCD := e.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+
+		return pTCD.PosX, pTCD.PosY
+	}
+	return 0, 0
+}
@@ -0,0 +1,163 @@
+package ecs
+
+import (
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+// LevelBounds is the area of the level in world coordinates.
+// Anything falling below DeathPlaneY is lost.
+type LevelBounds struct {
+	MinX        int32
+	MaxX        int32
+	MinY        int32
+	MaxY        int32
+	DeathPlaneY int32
+}
+
+// RespawnPoint is where the player comes back after dying
+type RespawnPoint struct {
+	PosX int32
+	PosY int32
+}
+
+type CheckpointComponentData struct {
+	Activated bool
+}
+
+type CheckpointSystem struct {
+	*CommonSystemData
+}
+
+func NewCheckpointSystem(e *ECSManager) *CheckpointSystem {
+	return &CheckpointSystem{
+		CommonSystemData: NewCommonSystemData("CHECKPOINT_COMPONENT", e),
+	}
+}
+
+func (sys *CheckpointSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	playerID, hasPlayer := ecsManager.FindPlayer()
+
+	if !hasPlayer {
+		return
+	}
+
+	sys.killEntitiesBelowDeathPlane(playerID)
+
+	playerRect := ecsManager.GetEntityRect(playerID)
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) {
+			continue
+		}
+
+		pCPCD := sys.GetComponentData(entityID).(*CheckpointComponentData)
+
+		if pCPCD.Activated || !playerRect.HasIntersection(ecsManager.GetEntityRect(entityID)) {
+			continue
+		}
+
+		sys.UpdateComponent(delta, entityID, pCPCD, playerID)
+	}
+}
+
+// UpdateComponent activates the touched checkpoint, all others are deactivated.
+// The player respawns standing on the checkpoint's base, no matter if it was touched mid-jump.
+func (sys *CheckpointSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	entityID := essentialData[0].(uint64)
+	pCPCD := essentialData[1].(*CheckpointComponentData)
+	playerID := essentialData[2].(uint64)
+
+	for el := sys.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		if sys.ECSManager.HasComponent(el.Value.([]uint16), sys.SystemID) {
+			sys.GetComponentData(el.Key.(uint64)).(*CheckpointComponentData).Activated = false
+		}
+	}
+
+	pCPCD.Activated = true
+
+	checkpointRect := sys.ECSManager.GetEntityRect(entityID)
+	playerRect := sys.ECSManager.GetEntityRect(playerID)
+
+	// The player might be crouching or jumping right now but respawns standing
+	if standingHeight, canCrouch := sys.ECSManager.standingHeight(playerID); canCrouch {
+		playerRect.H = standingHeight
+	}
+
+	sys.ECSManager.RespawnPoint = &RespawnPoint{
+		PosX: checkpointRect.X + (checkpointRect.W-playerRect.W)/2,
+		PosY: checkpointRect.Y + checkpointRect.H - playerRect.H,
+	}
+}
+
+// killEntitiesBelowDeathPlane lets the player die and removes everything else that fell out of the level
+func (sys *CheckpointSystem) killEntitiesBelowDeathPlane(playerID uint64) {
+	ecsManager := sys.ECSManager
+
+	if ecsManager.LevelBounds == nil {
+		return
+	}
+
+	for el := ecsManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasNamedComponent(components, "DYNAMIC_COMPONENT") || !ecsManager.HasNamedComponent(components, "TRANSFORM_COMPONENT") {
+			continue
+		}
+
+		pTCD := ecsManager.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+
+		if pTCD.PosY <= ecsManager.LevelBounds.DeathPlaneY {
+			continue
+		}
+
+		if entityID != playerID {
+			ecsManager.MarkEntityForRemoval(entityID)
+			continue
+		}
+
+		pHCD := ecsManager.GetComponentDataByName(entityID, "HEALTH_COMPONENT").(*HealthComponentData)
+
+		if pHCD.IsDead {
+			continue
+		}
+
+		pHCD.HP = 0
+		pHCD.IsDead = true
+		ecsManager.EmitEvent(Event{Type: ENTITY_DIED, EntityID: entityID})
+	}
+}
+
+// RespawnPlayer brings the player back to life at the last checkpoint,
+// or where it started the level if no checkpoint was touched yet
+func (e *ECSManager) RespawnPlayer(playerID uint64) {
+	if e.RespawnPoint == nil {
+		return
+	}
+
+	pTCD := e.GetComponentDataByName(playerID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+	pTCD.PosX = e.RespawnPoint.PosX
+	pTCD.PosY = e.RespawnPoint.PosY
+	pTCD.LastPosX = pTCD.PosX
+	pTCD.LastPosY = pTCD.PosY
+	pTCD.Hspeed = 0
+	pTCD.Vspeed = 0
+	pTCD.IsJumping = false
+	pTCD.IsCrouching = false
+	pTCD.IsNotMoving = true
+	pTCD.FlipImg = false
+
+	pHCD := e.GetComponentDataByName(playerID, "HEALTH_COMPONENT").(*HealthComponentData)
+	pHCD.HP = pHCD.MaxHP
+	pHCD.IsDead = false
+	pHCD.IsHurt = false
+	pHCD.invulnerableLeft = 0
+
+	e.SnapCameras()
+}
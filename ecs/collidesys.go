@@ -12,19 +12,34 @@ type CollisionCoreData struct {
 	LastCollisionDirection map[string]bool
 	IntersectRect          *sdl.Rect
 	EntityCollidingWith    uint64
+	// OnGround is reset every frame and set again when the entity lands on something.
+	// GroundFriction is the Friction of the entity it is standing on.
+	OnGround       bool
+	GroundFriction float64
 }
 
 type CollisionComponentData struct {
 	*CollisionCoreData
+	// Surface friction other entities experience when standing on this one.
+	// 1.0 is normal ground, lower values are slippery.
+	Friction float64
 }
 
 type CollideSystem struct {
 	*CommonSystemData
+	// Stands in for the collision data of the tile an entity collides with
+	tileCollision *CollisionComponentData
+	// Found this tick, for the debug overlay
+	intersections []sdl.Rect
+	flags         map[uint64]collisionFlags
 }
 
 func NewCollideSystem(e *ECSManager) *CollideSystem {
 	return &CollideSystem{
 		CommonSystemData: NewCommonSystemData("COLLIDE_COMPONENT", e),
+		tileCollision: &CollisionComponentData{
+			CollisionCoreData: &CollisionCoreData{CollisionDirection: make(map[string]bool), LastCollisionDirection: make(map[string]bool)},
+		},
 	}
 }
 
@@ -32,25 +47,43 @@ func (sys *CollideSystem) Run(delta float64, statemachine *statemachine.StateMac
 	ecsManager := sys.ECSManager
 	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
 
+	sys.intersections = sys.intersections[:0]
+	defer sys.publishDebugData()
+
 	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
 		components := el.Value.([]uint16)
 		entityID := el.Key.(uint64)
 
-		if !sys.ECSManager.HasNamedComponent(components, "DYNAMIC_COMPONENT") {
+		if !sys.ECSManager.HasNamedComponent(components, "DYNAMIC_COMPONENT") || !sys.ECSManager.HasComponent(components, sys.SystemID) {
 			continue
 		}
 
 		entityOneHasDynamicComponent := sys.ECSManager.HasNamedComponent(components, "DYNAMIC_COMPONENT")
-		lengthOfEntityComponentMap := uint64(entityToComponentMapOrdered.Len())
+		sys.GetComponentData(entityID).(*CollisionComponentData).OnGround = false
+
+		for elTwo := entityToComponentMapOrdered.Front(); elTwo != nil; elTwo = elTwo.Next() {
+			componentsEntityTwo := elTwo.Value.([]uint16)
+			j := elTwo.Key.(uint64)
+
+			if j == entityID || !sys.ECSManager.HasNamedComponent(componentsEntityTwo, "COLLIDE_COMPONENT") {
+				continue
+			}
 
-		for j := entityID + 1; j < lengthOfEntityComponentMap; j++ {
-			componentsEntityTwo, _ := entityToComponentMapOrdered.Get(j)
+			if sys.ECSManager.IsPassable(j, componentsEntityTwo) {
+				continue
+			}
 
-			if !sys.ECSManager.HasNamedComponent(componentsEntityTwo.([]uint16), "COLLIDE_COMPONENT") {
+			if sys.ECSManager.HasNamedComponent(componentsEntityTwo, "TILEMAP_COMPONENT") {
+				sys.collideWithTiles(delta, entityID, j, components)
 				continue
 			}
 
-			entityTwoHasDynamicComponent := sys.ECSManager.HasNamedComponent(componentsEntityTwo.([]uint16), "DYNAMIC_COMPONENT")
+			entityTwoHasDynamicComponent := sys.ECSManager.HasNamedComponent(componentsEntityTwo, "DYNAMIC_COMPONENT")
+
+			// Two dynamic entities were already checked when the one with the lower ID was entity one
+			if entityTwoHasDynamicComponent && j < entityID {
+				continue
+			}
 
 			ent1 := entityID
 			ent2 := j
@@ -59,6 +92,10 @@ func (sys *CollideSystem) Run(delta float64, statemachine *statemachine.StateMac
 				continue
 			}
 
+			if sys.ECSManager.IsMarkedForRemoval(ent1) || sys.ECSManager.IsMarkedForRemoval(ent2) {
+				continue
+			}
+
 			pTCD1 := sys.ECSManager.GetComponentDataByName(ent1, "TRANSFORM_COMPONENT").(*TransformComponentData)
 			pTCD2 := sys.ECSManager.GetComponentDataByName(ent2, "TRANSFORM_COMPONENT").(*TransformComponentData)
 			pRCD1 := sys.ECSManager.GetComponentDataByName(ent1, "RENDER_COMPONENT").(*RenderComponentData)
@@ -66,28 +103,242 @@ func (sys *CollideSystem) Run(delta float64, statemachine *statemachine.StateMac
 			pCCD1 := sys.GetComponentData(ent1).(*CollisionComponentData)
 			pCCD2 := sys.GetComponentData(ent2).(*CollisionComponentData)
 
-			if math.Abs(float64(pTCD1.PosX-pTCD2.PosX)) > float64(pRCD1.Image.W/2) && math.Abs(float64(pTCD1.PosY-pTCD2.PosY)) > float64(pRCD1.Image.H/2) ||
-				math.Abs(float64(pTCD1.PosX-pTCD2.PosX)) > float64(pRCD2.Image.W/2) && math.Abs(float64(pTCD1.PosY-pTCD2.PosY)) > float64(pRCD2.Image.H/2) {
+			w1, h1 := pRCD1.Size()
+			w2, h2 := pRCD2.Size()
+
+			if math.Abs(float64(pTCD1.PosX-pTCD2.PosX)) > float64(w1/2) && math.Abs(float64(pTCD1.PosY-pTCD2.PosY)) > float64(h1/2) ||
+				math.Abs(float64(pTCD1.PosX-pTCD2.PosX)) > float64(w2/2) && math.Abs(float64(pTCD1.PosY-pTCD2.PosY)) > float64(h2/2) {
 
 				// Skip entities that are to far away from each other anyways
 				continue
 			}
 
+			entityOneIsProjectile := sys.ECSManager.HasNamedComponent(components, "PROJECTILE_COMPONENT")
+			entityTwoIsProjectile := sys.ECSManager.HasNamedComponent(componentsEntityTwo, "PROJECTILE_COMPONENT")
+
+			if entityOneIsProjectile || entityTwoIsProjectile {
+				// Projectiles never push anything around, they just hit
+				sys.projectileHit(ent1, ent2, entityOneIsProjectile, entityTwoIsProjectile)
+				continue
+			}
+
 			intersectRect, areColliding := sys.detect(sys.ECSManager, ent1, ent2, pTCD1, pTCD2, pCCD1, pCCD2)
 
 			if !areColliding || intersectRect == nil {
 				continue
 			}
 
+			// Has to be decided before resolving, which pushes entity one out of entity two
+			hitFromBelow := pCCD1.CollisionDirection["top"] && pTCD1.PosY < pTCD1.LastPosY && intersectRect.W >= intersectRect.H
+
 			sys.UpdateComponent(delta, intersectRect, pTCD1, pTCD2, entityOneHasDynamicComponent, entityTwoHasDynamicComponent, pCCD1, pCCD2)
+
+			if hitFromBelow && sys.ECSManager.HasNamedComponent(components, "ACTIVE_CONTROL_COMPONENT") {
+				sys.ECSManager.DamageBreakable(ent2, 1)
+			}
+
+			// After resolving, otherwise landing on a hazard would swallow the knockback
+			sys.contactDamage(ent1, ent2, components, componentsEntityTwo)
+		}
+	}
+}
+
+// publishDebugData hands the intersections and collision flags of this tick to the debug overlay.
+// The render goroutine only gets copies, the CollisionDirection maps are written while it draws.
+func (sys *CollideSystem) publishDebugData() {
+	ecsManager := sys.ECSManager
+
+	if !ecsManager.DebugDraw && len(ecsManager.debugIntersections) == 0 && len(ecsManager.debugFlags) == 0 {
+		return
+	}
+
+	if sys.flags == nil {
+		sys.flags = make(map[uint64]collisionFlags)
+	}
+
+	for entityID := range sys.flags {
+		delete(sys.flags, entityID)
+	}
+
+	if ecsManager.DebugDraw {
+		for el := ecsManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+			if ecsManager.HasComponent(el.Value.([]uint16), sys.SystemID) {
+				entityID := el.Key.(uint64)
+				sys.flags[entityID] = newCollisionFlags(sys.GetComponentData(entityID).(*CollisionComponentData))
+			}
+		}
+	}
+
+	// The render goroutine might be drawing the ones of the last tick right now
+	ecsManager.entityMu.Lock()
+	ecsManager.debugIntersections = ecsManager.debugIntersections[:0]
+
+	if ecsManager.DebugDraw {
+		ecsManager.debugIntersections = append(ecsManager.debugIntersections, sys.intersections...)
+	}
+
+	// The flags of the last tick are filled next tick
+	ecsManager.debugFlags, sys.flags = sys.flags, ecsManager.debugFlags
+	ecsManager.entityMu.Unlock()
+}
+
+// IsPassable reports whether a collidable entity can be moved through right now,
+// which is the case for open doors and broken tiles
+func (e *ECSManager) IsPassable(entityID uint64, components []uint16) bool {
+	if e.IsOpenDoor(entityID, components) {
+		return true
+	}
+
+	if !e.HasNamedComponent(components, "BREAKABLE_COMPONENT") {
+		return false
+	}
+
+	return e.GetComponentDataByName(entityID, "BREAKABLE_COMPONENT").(*BreakableComponentData).Broken
+}
+
+// SolidAt reports whether the rectangle overlaps any static (non dynamic) collidable entity
+func (e *ECSManager) SolidAt(rect *sdl.Rect, exclude uint64) bool {
+	for el := e.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if entityID == exclude || !e.HasNamedComponent(components, "COLLIDE_COMPONENT") || e.HasNamedComponent(components, "DYNAMIC_COMPONENT") {
+			continue
+		}
+
+		if e.IsPassable(entityID, components) {
+			continue
+		}
+
+		if e.HasNamedComponent(components, "TILEMAP_COMPONENT") {
+			if e.solidTileAt(entityID, rect) {
+				return true
+			}
+			continue
 		}
+
+		if rect.HasIntersection(e.GetEntityRect(entityID)) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectileHit removes a projectile that overlaps anything but its owner or another projectile
+func (sys *CollideSystem) projectileHit(ent1, ent2 uint64, entityOneIsProjectile, entityTwoIsProjectile bool) {
+	if entityOneIsProjectile && entityTwoIsProjectile {
+		return
+	}
+
+	projectile, target := ent1, ent2
+
+	if entityTwoIsProjectile {
+		projectile, target = ent2, ent1
+	}
+
+	pPCD := sys.ECSManager.GetComponentDataByName(projectile, "PROJECTILE_COMPONENT").(*ProjectileComponentData)
+
+	if pPCD.Owner == target {
+		return
+	}
+
+	projectileRect := sys.ECSManager.GetEntityRect(projectile)
+
+	if _, areColliding := projectileRect.Intersect(sys.ECSManager.GetEntityRect(target)); !areColliding {
+		return
+	}
+
+	sys.ECSManager.DamageEntity(target, pPCD.Damage, projectileRect.X+projectileRect.W/2)
+	sys.ECSManager.DamageBreakable(target, pPCD.Damage)
+	sys.ECSManager.MarkEntityForRemoval(projectile)
+}
+
+// collideWithTiles collides a dynamic entity with every solid tile of the tilemap it overlaps,
+// just like with a static entity in the place of each tile
+func (sys *CollideSystem) collideWithTiles(delta float64, entityID, tilemapID uint64, components []uint16) {
+	if sys.ECSManager.IsMarkedForRemoval(entityID) {
+		return
+	}
+
+	pTMCD := sys.ECSManager.GetComponentDataByName(tilemapID, "TILEMAP_COMPONENT").(*TilemapComponentData)
+	pTCDMap := sys.ECSManager.GetComponentDataByName(tilemapID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+	pTCD := sys.ECSManager.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+	pCCD := sys.GetComponentData(entityID).(*CollisionComponentData)
+
+	firstColumn, firstRow, lastColumn, lastRow, overlaps := pTMCD.cellRange(sys.ECSManager.GetEntityRect(entityID), pTCDMap.PosX, pTCDMap.PosY)
+
+	if !overlaps {
+		return
+	}
+
+	isProjectile := sys.ECSManager.HasNamedComponent(components, "PROJECTILE_COMPONENT")
+
+	for row := firstRow; row <= lastRow; row++ {
+		for column := firstColumn; column <= lastColumn; column++ {
+			tile := pTMCD.TileAt(column, row)
+
+			if tile == nil || !tile.Solid {
+				continue
+			}
+
+			tileRect := pTMCD.tileRect(tile, column, row, pTCDMap.PosX, pTCDMap.PosY)
+
+			if isProjectile {
+				// Projectiles just hit the ground
+				if sys.ECSManager.GetEntityRect(entityID).HasIntersection(tileRect) {
+					sys.ECSManager.MarkEntityForRemoval(entityID)
+					return
+				}
+				continue
+			}
+
+			pCCDTile := sys.tileCollision
+			pCCDTile.Friction = tile.Friction
+
+			for direction := range pCCDTile.CollisionDirection {
+				delete(pCCDTile.CollisionDirection, direction)
+			}
+
+			// Resolving a collision moves the entity, so its rect has to be taken again for every tile
+			intersectRect, areColliding := sys.detectRects(sys.ECSManager.GetEntityRect(entityID), tileRect, pTCD, pCCD, pCCDTile)
+
+			if !areColliding || intersectRect == nil {
+				continue
+			}
+
+			sys.UpdateComponent(delta, intersectRect, pTCD, pTCDMap, true, false, pCCD, pCCDTile)
+		}
+	}
+}
+
+// contactDamage lets entities with a DAMAGE_COMPONENT hurt the entity they collide with
+func (sys *CollideSystem) contactDamage(ent1, ent2 uint64, componentsEntityOne, componentsEntityTwo []uint16) {
+	pairs := [2][2]uint64{{ent1, ent2}, {ent2, ent1}}
+	damaging := [2]bool{
+		sys.ECSManager.HasNamedComponent(componentsEntityOne, "DAMAGE_COMPONENT"),
+		sys.ECSManager.HasNamedComponent(componentsEntityTwo, "DAMAGE_COMPONENT"),
+	}
+
+	for i, pair := range pairs {
+		source, target := pair[0], pair[1]
+
+		if !damaging[i] {
+			continue
+		}
+
+		pDCD := sys.ECSManager.GetComponentDataByName(source, "DAMAGE_COMPONENT").(*DamageComponentData)
+		sourceRect := sys.ECSManager.GetEntityRect(source)
+
+		sys.ECSManager.DamageEntity(target, pDCD.Damage, sourceRect.X+sourceRect.W/2)
 	}
 }
 
 func (sys *CollideSystem) detect(ecsManager *ECSManager, ent1, ent2 uint64, pTCD1, pTCD2 *TransformComponentData, pCCD1, pCCD2 *CollisionComponentData) (*sdl.Rect, bool) {
-	imgRectOne := sys.ECSManager.GetEntityRect(ent1)
-	imgRectTwo := sys.ECSManager.GetEntityRect(ent2)
+	return sys.detectRects(sys.ECSManager.GetEntityRect(ent1), sys.ECSManager.GetEntityRect(ent2), pTCD1, pCCD1, pCCD2)
+}
 
+// detectRects is detect for anything that is not an entity on its own, such as a tile
+func (sys *CollideSystem) detectRects(imgRectOne, imgRectTwo *sdl.Rect, pTCD1 *TransformComponentData, pCCD1, pCCD2 *CollisionComponentData) (*sdl.Rect, bool) {
 	type Line struct {
 		pointA *sdl.Point
 		pointB *sdl.Point
@@ -96,7 +347,9 @@ func (sys *CollideSystem) detect(ecsManager *ECSManager, ent1, ent2 uint64, pTCD
 	intersectRect, areColliding := imgRectOne.Intersect(imgRectTwo)
 
 	if areColliding {
-		log.Println("Entities ARE colliding. Checking from what directions.")
+		if sys.ECSManager.LogCollisions {
+			log.Println("Entities ARE colliding. Checking from what directions.")
+		}
 		topLineRectOne := Line{pointA: &sdl.Point{X: imgRectOne.X, Y: imgRectOne.Y}, pointB: &sdl.Point{X: imgRectOne.X + imgRectOne.W, Y: imgRectOne.Y}}
 		leftLineRectOne := Line{pointA: topLineRectOne.pointA, pointB: &sdl.Point{X: imgRectOne.X, Y: imgRectOne.Y + imgRectOne.H}}
 		bottomLineRectOne := Line{pointA: leftLineRectOne.pointB, pointB: &sdl.Point{X: imgRectOne.X + imgRectOne.W, Y: leftLineRectOne.pointB.Y}}
@@ -137,7 +390,14 @@ func (sys *CollideSystem) detect(ecsManager *ECSManager, ent1, ent2 uint64, pTCD
 			pCCD1.CollisionDirection["bottom"] = false
 		}
 
-		log.Println(intersectRect, areColliding)
+		if sys.ECSManager.LogCollisions {
+			log.Println(intersectRect, areColliding)
+		}
+
+		if sys.ECSManager.DebugDraw {
+			sys.intersections = append(sys.intersections, intersectRect)
+		}
+
 		return &intersectRect, areColliding
 	}
 
@@ -145,8 +405,11 @@ func (sys *CollideSystem) detect(ecsManager *ECSManager, ent1, ent2 uint64, pTCD
 }
 
 func (sys *CollideSystem) resolve(intersectRect *sdl.Rect, pTCD1, pTCD2 *TransformComponentData, entityOneHasDynamicComponent, entityTwoHasDynamicComponent bool, pCCD1, pCCD2 *CollisionComponentData) {
-	log.Println(pCCD1.CollisionDirection)
-	log.Println(pCCD2.CollisionDirection)
+	if sys.ECSManager.LogCollisions {
+		log.Println(pCCD1.CollisionDirection)
+		log.Println(pCCD2.CollisionDirection)
+	}
+
 	if entityOneHasDynamicComponent && pCCD1.CollisionDirection["right"] && !pCCD1.LastCollisionDirection["right"] {
 		pTCD1.PosX -= intersectRect.W
 		pTCD1.Hspeed = 0
@@ -161,6 +424,8 @@ func (sys *CollideSystem) resolve(intersectRect *sdl.Rect, pTCD1, pTCD2 *Transfo
 		pTCD1.PosY -= intersectRect.H
 		pTCD1.Vspeed = 0
 		pTCD1.IsJumping = false
+		pCCD1.OnGround = true
+		pCCD1.GroundFriction = pCCD2.Friction
 	}
 
 	if entityOneHasDynamicComponent && pCCD1.CollisionDirection["top"] {
@@ -0,0 +1,282 @@
+package ecs
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// DEBUG_VELOCITY_SCALE stretches the velocity vectors, one tick of movement is too short to see
+const DEBUG_VELOCITY_SCALE int32 = 4
+
+var (
+	debugColorPlayer       = sdl.Color{R: 0, G: 255, B: 0, A: 255}
+	debugColorEnemy        = sdl.Color{R: 255, G: 0, B: 0, A: 255}
+	debugColorProjectile   = sdl.Color{R: 255, G: 255, B: 0, A: 255}
+	debugColorDynamic      = sdl.Color{R: 255, G: 140, B: 0, A: 255}
+	debugColorStatic       = sdl.Color{R: 0, G: 120, B: 255, A: 255}
+	debugColorIntersection = sdl.Color{R: 255, G: 0, B: 255, A: 255}
+	debugColorVelocity     = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+)
+
+// debugOverlay draws what the CollideSystem works with on top of the frame
+type debugOverlay struct {
+	// Rendered labels by entity, labels rarely change so they are kept as long as their entity is drawn
+	labels map[uint64]*debugLabelTexture
+}
+
+type debugLabelTexture struct {
+	text    string
+	texture *sdl.Texture
+	// Labels not drawn in a frame are destroyed at its end
+	drawn bool
+}
+
+// ToggleDebugDraw shows or hides the debug overlay
+func (e *ECSManager) ToggleDebugDraw() {
+	e.entityMu.Lock()
+	e.DebugDraw = !e.DebugDraw
+	e.entityMu.Unlock()
+
+	log.Printf("Debug overlay shown: %t\n", e.DebugDraw)
+}
+
+// debugColor tells entities apart by their components
+func (e *ECSManager) debugColor(components []uint16) sdl.Color {
+	switch {
+	case e.HasNamedComponent(components, "ACTIVE_CONTROL_COMPONENT"):
+		return debugColorPlayer
+	case e.HasNamedComponent(components, "PROJECTILE_COMPONENT"):
+		return debugColorProjectile
+	case e.HasNamedComponent(components, "PASSIVE_CONTROL_COMPONENT_NPC") || e.HasNamedComponent(components, "BEHAVIOUR_COMPONENT") ||
+		e.HasNamedComponent(components, "DAMAGE_COMPONENT"):
+		return debugColorEnemy
+	case e.HasNamedComponent(components, "DYNAMIC_COMPONENT"):
+		return debugColorDynamic
+	default:
+		return debugColorStatic
+	}
+}
+
+// collisionFlags is a copy of an entity's collision state for the debug overlay
+type collisionFlags struct {
+	Top      bool
+	Bottom   bool
+	Left     bool
+	Right    bool
+	OnGround bool
+}
+
+func newCollisionFlags(pCCD *CollisionComponentData) collisionFlags {
+	return collisionFlags{
+		Top:      pCCD.CollisionDirection["top"],
+		Bottom:   pCCD.CollisionDirection["bottom"],
+		Left:     pCCD.CollisionDirection["left"],
+		Right:    pCCD.CollisionDirection["right"],
+		OnGround: pCCD.OnGround,
+	}
+}
+
+// debugLabel is the entity's ID followed by its collision flags:
+// T, B, L and R for the directions it collides from and G if it stands on the ground
+func debugLabel(entityID uint64, flags collisionFlags) string {
+	var letters strings.Builder
+
+	if flags.Top {
+		letters.WriteString("T")
+	}
+
+	if flags.Bottom {
+		letters.WriteString("B")
+	}
+
+	if flags.Left {
+		letters.WriteString("L")
+	}
+
+	if flags.Right {
+		letters.WriteString("R")
+	}
+
+	if flags.OnGround {
+		letters.WriteString("G")
+	}
+
+	if letters.Len() == 0 {
+		return fmt.Sprintf("%d", entityID)
+	}
+
+	return fmt.Sprintf("%d %s", entityID, letters.String())
+}
+
+// renderDebugOverlay draws colliders, the intersections and collision flags the CollideSystem
+// published last tick, velocities and entity IDs. The entity lock must be held.
+func (sys *RenderSystem) renderDebugOverlay(cameraX, cameraY int32, viewport *sdl.Rect) {
+	ecsManager := sys.ECSManager
+
+	if !ecsManager.DebugDraw {
+		sys.debug.freeLabels()
+		return
+	}
+
+	r, g, b, a, _ := sys.Renderer.GetDrawColor()
+	defer sys.Renderer.SetDrawColor(r, g, b, a)
+
+	for el := ecsManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasNamedComponent(components, "COLLIDE_COMPONENT") || !ecsManager.HasNamedComponent(components, "TRANSFORM_COMPONENT") {
+			continue
+		}
+
+		var offsetX, offsetY int32
+
+		if ecsManager.HasNamedComponent(components, "REAL_COMPONENT") {
+			offsetX, offsetY = cameraX, cameraY
+		}
+
+		if ecsManager.HasNamedComponent(components, "TILEMAP_COMPONENT") {
+			sys.renderDebugTiles(entityID, offsetX, offsetY, viewport)
+			continue
+		}
+
+		rect := ecsManager.GetEntityRect(entityID)
+		rect.X -= offsetX
+		rect.Y -= offsetY
+
+		if !rect.HasIntersection(viewport) {
+			continue
+		}
+
+		setDrawColor(sys.Renderer, ecsManager.debugColor(components))
+		_ = sys.Renderer.DrawRect(rect)
+
+		pTCD := ecsManager.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+
+		if pTCD.Hspeed != 0 || pTCD.Vspeed != 0 {
+			centerX, centerY := rect.X+rect.W/2, rect.Y+rect.H/2
+
+			// Vspeed points up, the screen's Y axis down
+			setDrawColor(sys.Renderer, debugColorVelocity)
+			_ = sys.Renderer.DrawLine(centerX, centerY, centerX+pTCD.Hspeed*DEBUG_VELOCITY_SCALE, centerY-pTCD.Vspeed*DEBUG_VELOCITY_SCALE)
+		}
+
+		// Missing until the CollideSystem published its first flags, drawn without flags then
+		sys.renderDebugLabel(entityID, debugLabel(entityID, ecsManager.debugFlags[entityID]), rect.X, rect.Y)
+	}
+
+	// Labels of entities that are gone or off screen
+	sys.debug.freeUndrawnLabels()
+
+	setDrawColor(sys.Renderer, debugColorIntersection)
+
+	for _, intersection := range ecsManager.debugIntersections {
+		intersection.X -= cameraX
+		intersection.Y -= cameraY
+		_ = sys.Renderer.FillRect(&intersection)
+	}
+}
+
+// renderDebugTiles outlines the visible solid tiles of a tilemap
+func (sys *RenderSystem) renderDebugTiles(entityID uint64, offsetX, offsetY int32, viewport *sdl.Rect) {
+	pTMCD := sys.ECSManager.GetComponentDataByName(entityID, "TILEMAP_COMPONENT").(*TilemapComponentData)
+	pTCD := sys.ECSManager.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+
+	originX, originY := pTCD.PosX-offsetX, pTCD.PosY-offsetY
+	firstColumn, firstRow, lastColumn, lastRow, visible := pTMCD.cellRange(viewport, originX, originY)
+
+	if !visible {
+		return
+	}
+
+	setDrawColor(sys.Renderer, debugColorStatic)
+
+	for row := firstRow; row <= lastRow; row++ {
+		for column := firstColumn; column <= lastColumn; column++ {
+			if tile := pTMCD.TileAt(column, row); tile != nil && tile.Solid {
+				_ = sys.Renderer.DrawRect(pTMCD.tileRect(tile, column, row, originX, originY))
+			}
+		}
+	}
+}
+
+// renderDebugLabel draws the entity's label right above the given point.
+// The label's texture is only rendered again when its text changed.
+func (sys *RenderSystem) renderDebugLabel(entityID uint64, text string, x, y int32) {
+	label, ok := sys.debug.labels[entityID]
+
+	if !ok || label.text != text {
+		if ok {
+			_ = label.texture.Destroy()
+			delete(sys.debug.labels, entityID)
+		}
+
+		texture, err := sys.renderDebugLabelTexture(text)
+
+		if err != nil {
+			return
+		}
+
+		if sys.debug.labels == nil {
+			sys.debug.labels = make(map[uint64]*debugLabelTexture)
+		}
+
+		label = &debugLabelTexture{text: text, texture: texture}
+		sys.debug.labels[entityID] = label
+	}
+
+	label.drawn = true
+
+	_, _, w, h, err := label.texture.Query()
+
+	if err != nil {
+		return
+	}
+
+	_ = sys.Renderer.Copy(label.texture, nil, &sdl.Rect{X: x, Y: y - h, W: w, H: h})
+}
+
+func (sys *RenderSystem) renderDebugLabelTexture(text string) (*sdl.Texture, error) {
+	font, err := sys.ECSManager.Fonts.Get(DEFAULT_FONT, 12)
+
+	if err != nil {
+		return nil, err
+	}
+
+	surface, err := font.RenderUTF8Blended(text, debugColorVelocity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer surface.Free()
+
+	return sys.Renderer.CreateTextureFromSurface(surface)
+}
+
+// freeUndrawnLabels destroys the labels that were not drawn since it was called last
+func (d *debugOverlay) freeUndrawnLabels() {
+	for entityID, label := range d.labels {
+		if label.drawn {
+			label.drawn = false
+			continue
+		}
+
+		_ = label.texture.Destroy()
+		delete(d.labels, entityID)
+	}
+}
+
+func (d *debugOverlay) freeLabels() {
+	for entityID, label := range d.labels {
+		_ = label.texture.Destroy()
+		delete(d.labels, entityID)
+	}
+}
+
+func setDrawColor(renderer *sdl.Renderer, color sdl.Color) {
+	_ = renderer.SetDrawColor(color.R, color.G, color.B, color.A)
+}
@@ -0,0 +1,89 @@
+package ecs
+
+import (
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type DoorComponentData struct {
+	Open bool
+	// A door with a KeyName opens for good once the player touches it with that key
+	KeyName string
+	// Entity IDs of the switches linked to the door. The door is open while any of them is on.
+	Switches []uint64
+	unlocked bool
+}
+
+// DoorSystem opens and closes doors. Open doors are not collided with, see CollideSystem.
+type DoorSystem struct {
+	*CommonSystemData
+}
+
+func NewDoorSystem(e *ECSManager) *DoorSystem {
+	return &DoorSystem{
+		CommonSystemData: NewCommonSystemData("DOOR_COMPONENT", e),
+	}
+}
+
+func (sys *DoorSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	playerID, hasPlayer := ecsManager.FindPlayer()
+
+	if !hasPlayer {
+		return
+	}
+
+	// A bit bigger than the player, a closed door never overlaps the player it blocks
+	playerRect := ecsManager.GetEntityRect(playerID)
+	reach := &sdl.Rect{X: playerRect.X - 2, Y: playerRect.Y - 2, W: playerRect.W + 4, H: playerRect.H + 4}
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) {
+			continue
+		}
+
+		pDCD := sys.GetComponentData(entityID).(*DoorComponentData)
+		doorRect := ecsManager.GetEntityRect(entityID)
+
+		sys.UpdateComponent(delta, pDCD, reach.HasIntersection(doorRect), playerRect.HasIntersection(doorRect))
+	}
+}
+
+func (sys *DoorSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	pDCD := essentialData[0].(*DoorComponentData)
+	playerTouches := essentialData[1].(bool)
+	playerInside := essentialData[2].(bool)
+
+	if pDCD.KeyName != "" && playerTouches && sys.ECSManager.Inventory.Keys[pDCD.KeyName] {
+		pDCD.unlocked = true
+	}
+
+	open := pDCD.unlocked
+
+	for _, switchID := range pDCD.Switches {
+		if sys.ECSManager.GetComponentDataByName(switchID, "SWITCH_COMPONENT").(*SwitchComponentData).On {
+			open = true
+		}
+	}
+
+	// Never shut a door on the player
+	if !open && pDCD.Open && playerInside {
+		return
+	}
+
+	pDCD.Open = open
+}
+
+// IsOpenDoor reports whether the entity is a door that can be walked through right now
+func (e *ECSManager) IsOpenDoor(entityID uint64, components []uint16) bool {
+	if !e.HasNamedComponent(components, "DOOR_COMPONENT") {
+		return false
+	}
+
+	return e.GetComponentDataByName(entityID, "DOOR_COMPONENT").(*DoorComponentData).Open
+}
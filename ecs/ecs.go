@@ -2,10 +2,13 @@ package ecs
 
 import (
 	"github.com/elliotchance/orderedmap"
+	"github.com/t-puetz/GoJumpAndRunAndShoot/behaviourtree"
 	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
 	"github.com/veandco/go-sdl2/sdl"
+	"log"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 /*
@@ -56,6 +59,11 @@ import (
  8 - Animate
  ...
 
+ Entities can also be created and removed while the game is running (e.g. projectiles).
+ They are spawned from Prefabs, which are assets that were loaded once with the level.
+ Removal is deferred: systems only mark entities and the game removes them after all
+ systems ran, so no system ever sees the ordered map change while iterating it.
+
  65534 is the maximum of allowed components.
  65535 is used as the value for NO COMPONENT CONNECTED YET.
  Using 65534 components is super unlikely.
@@ -90,6 +98,44 @@ type ECSManager struct {
 	ComponentIDStorage                      map[string]uint16
 	ComponentData                           *ComponentData
 	Systems                                 []System
+	Prefabs                                 map[string]*Prefab
+	// Every image of an entity, prefab or parallax layer is acquired from here
+	Textures *TextureCache
+	Fonts    *FontCache
+	// Loot tables by name, the prefabs they drop are loaded with the level
+	LootTables map[string][]LootDrop
+	// Drawn behind all entities, the first layer furthest back
+	ParallaxLayers []*ParallaxLayer
+	// Survives level changes, belongs to the current run
+	Inventory *Inventory
+	// Milliseconds played in the current level, counted by the HudSystem
+	LevelTime float64
+	// Shows the debug overlay, see ToggleDebugDraw
+	DebugDraw bool
+	// Lets the CollideSystem log every collision it detects and resolves
+	LogCollisions bool
+	// Intersections and collision flags of the last tick, published by the CollideSystem while DebugDraw is set
+	debugIntersections []sdl.Rect
+	debugFlags         map[uint64]collisionFlags
+	// nil if the level has no bounds
+	LevelBounds  *LevelBounds
+	RespawnPoint *RespawnPoint
+
+	entitiesToRemove map[uint64]bool
+	events           []Event
+	// Guards EntityToComponentMap against the render goroutine while entities are added or removed
+	entityMu sync.Mutex
+}
+
+// Prefab is everything needed to spawn an entity at runtime
+type Prefab struct {
+	Reference  string
+	Components []uint16
+	Path       string
+	Image      *sdl.Surface
+	Texture    *sdl.Texture
+	// Copied to the spawned entity's PICKUP_COMPONENT, nil if the prefab is no pickup
+	Pickup *PickupComponentData
 }
 
 func NewECSManager() *ECSManager {
@@ -107,14 +153,35 @@ func NewECSManager() *ECSManager {
 	componentNameToIDMap["RENDER_COMPONENT"] = 8
 	componentNameToIDMap["ANIMATE_COMPONENT"] = 9
 	componentNameToIDMap["PASSIVE_CONTROL_COMPONENT_NPC"] = 10
+	// Has no data and no system anymore, scrolling is done by the CameraSystem.
+	// Still registered so level descriptions using it stay valid.
 	componentNameToIDMap["SIDE_SCROLL_COMPONENT"] = 11
+	componentNameToIDMap["PROJECTILE_COMPONENT"] = 12
+	componentNameToIDMap["HEALTH_COMPONENT"] = 13
+	componentNameToIDMap["DAMAGE_COMPONENT"] = 14
+	componentNameToIDMap["BEHAVIOUR_COMPONENT"] = 15
+	componentNameToIDMap["PICKUP_COMPONENT"] = 16
+	componentNameToIDMap["INVENTORY_DISPLAY_COMPONENT"] = 17
+	componentNameToIDMap["CHECKPOINT_COMPONENT"] = 18
+	componentNameToIDMap["GOAL_COMPONENT"] = 19
+	componentNameToIDMap["SWITCH_COMPONENT"] = 20
+	componentNameToIDMap["DOOR_COMPONENT"] = 21
+	componentNameToIDMap["BREAKABLE_COMPONENT"] = 22
+	componentNameToIDMap["CAMERA_COMPONENT"] = 23
+	componentNameToIDMap["TILEMAP_COMPONENT"] = 24
+	componentNameToIDMap["TEXT_COMPONENT"] = 25
+	componentNameToIDMap["HUD_COMPONENT"] = 26
 
 	ecsManager := ECSManager{
 		EntityToComponentMap:                    orderedmap.NewOrderedMap(),
 		EntityComponentStringToComponentDataMap: entityComponentStringToComponentDataMap,
 		ComponentIDStorage:                      componentNameToIDMap,
 		ComponentData:                           nil,
-		Systems:                                 make([]System, 7, 8),
+		Systems:                                 make([]System, 0, 8),
+		Prefabs:                                 make(map[string]*Prefab),
+		LootTables:                              make(map[string][]LootDrop),
+		Inventory:                               NewInventory(),
+		entitiesToRemove:                        make(map[uint64]bool),
 	}
 
 	return &ecsManager
@@ -173,55 +240,285 @@ func (e *ECSManager) AddComponentToEntity(entityID uint64, componentID uint16) {
 }
 
 func (e *ECSManager) LinkComponentsWithProperDataStruct() {
-	entityComponentStringToComponentDataMap := e.EntityComponentStringToComponentDataMap
-	entityToComponentMap := *e.EntityToComponentMap
+	// A new set of entities, marks of the old one are meaningless now
+	e.entitiesToRemove = make(map[uint64]bool)
+	e.events = nil
+	e.LevelTime = 0
 
-	curNumEntities := uint64(entityToComponentMap.Len())
-	var sideScrollCounterX float64
+	for el := e.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		e.linkComponentsOfEntity(el.Key.(uint64))
+	}
+}
 
-	for i := uint64(0); i < curNumEntities; i++ {
-		entityIDStr := strconv.Itoa(int(i))
+func (e *ECSManager) linkComponentsOfEntity(entityID uint64) {
+	entityComponentStringToComponentDataMap := e.EntityComponentStringToComponentDataMap
+	entityIDStr := strconv.Itoa(int(entityID))
+
+	var componentIDStr string
+	var keyForEntityComponentDataMap string
+	componentMap, _ := e.EntityToComponentMap.Get(entityID)
 
-		var componentIDStr string
-		var keyForEntityComponentDataMap string
-		componentMap, _ := entityToComponentMap.Get(i)
+	for j, _ := range componentMap.([]uint16) {
+		if j != 65535 {
+			componentIDStr = strconv.Itoa(j)
+		}
 
-		for j, _ := range componentMap.([]uint16) {
-			if j != 65535 {
-				componentIDStr = strconv.Itoa(j)
+		keyForEntityComponentDataMap = entityIDStr + "-" + componentIDStr
+		cd := ComponentData{Data: nil}
+
+		// Only link data if entityComponentMap map's val for that key is nil
+		// Also only link data if a real component exists.
+
+		if keyForEntityComponentDataMap != "-" && j != 65535 {
+			switch uint16(j) {
+			case e.ComponentIDStorage["TRANSFORM_COMPONENT"]:
+				cd.Data = &TransformComponentData{}
+			case e.ComponentIDStorage["RENDER_COMPONENT"]:
+				cd.Data = &RenderComponentData{}
+			case e.ComponentIDStorage["ANIMATE_COMPONENT"]:
+				acd := make(map[string]*AnimationComponentDataCore)
+				cd.Data = &AnimateComponentData{AnimationData: &acd}
+			case e.ComponentIDStorage["ACTIVE_CONTROL_COMPONENT"]:
+				cd.Data = &ActiveControlComponentData{}
+			case e.ComponentIDStorage["GRAVITY_COMPONENT"]:
+				cd.Data = &GravityComponentData{}
+			case e.ComponentIDStorage["COLLIDE_COMPONENT"]:
+				collisionCoreData := &CollisionCoreData{CollisionDirection: make(map[string]bool), LastCollisionDirection: make(map[string]bool)}
+				collisionCoreData.CollisionDirection["bottom"] = true
+				collisionCoreData.LastCollisionDirection["bottom"] = true
+				collisionCoreData.GroundFriction = 1.0
+				cd.Data = &CollisionComponentData{CollisionCoreData: collisionCoreData, Friction: 1.0}
+			case e.ComponentIDStorage["PROJECTILE_COMPONENT"]:
+				cd.Data = &ProjectileComponentData{}
+			case e.ComponentIDStorage["PASSIVE_CONTROL_COMPONENT_NPC"]:
+				cd.Data = &PassiveControlComponentData{}
+			case e.ComponentIDStorage["HEALTH_COMPONENT"]:
+				cd.Data = &HealthComponentData{}
+			case e.ComponentIDStorage["DAMAGE_COMPONENT"]:
+				cd.Data = &DamageComponentData{}
+			case e.ComponentIDStorage["BEHAVIOUR_COMPONENT"]:
+				cd.Data = &BehaviourComponentData{Blackboard: make(behaviourtree.Blackboard)}
+			case e.ComponentIDStorage["PICKUP_COMPONENT"]:
+				cd.Data = &PickupComponentData{}
+			case e.ComponentIDStorage["CHECKPOINT_COMPONENT"]:
+				cd.Data = &CheckpointComponentData{}
+			case e.ComponentIDStorage["GOAL_COMPONENT"]:
+				cd.Data = &GoalComponentData{}
+			case e.ComponentIDStorage["SWITCH_COMPONENT"]:
+				cd.Data = &SwitchComponentData{}
+			case e.ComponentIDStorage["DOOR_COMPONENT"]:
+				cd.Data = &DoorComponentData{}
+			case e.ComponentIDStorage["BREAKABLE_COMPONENT"]:
+				cd.Data = &BreakableComponentData{}
+			case e.ComponentIDStorage["CAMERA_COMPONENT"]:
+				cd.Data = &CameraComponentData{}
+			case e.ComponentIDStorage["TILEMAP_COMPONENT"]:
+				cd.Data = &TilemapComponentData{}
+			case e.ComponentIDStorage["TEXT_COMPONENT"]:
+				cd.Data = &TextComponentData{}
+			case e.ComponentIDStorage["HUD_COMPONENT"]:
+				cd.Data = &HudComponentData{}
 			}
+		}
+		entityComponentStringToComponentDataMap[keyForEntityComponentDataMap] = &cd
+	}
+}
+
+// CreateEntity adds a new entity with the given components behind all existing entities
+// and links its component data. It returns the new entity's ID.
+func (e *ECSManager) CreateEntity(components []uint16) uint64 {
+	e.entityMu.Lock()
+	defer e.entityMu.Unlock()
+
+	var entityID uint64
+
+	if lastEntity := e.EntityToComponentMap.Back(); lastEntity != nil {
+		entityID = lastEntity.Key.(uint64) + 1
+	}
+
+	e.InitializeComponentsForEntity(entityID)
+
+	for _, componentID := range components {
+		e.AddComponentToEntity(entityID, componentID)
+	}
+
+	e.linkComponentsOfEntity(entityID)
+
+	return entityID
+}
+
+// SpawnPrefab creates an entity from the named prefab at the given position
+func (e *ECSManager) SpawnPrefab(prefabName string, posX, posY int32) (uint64, bool) {
+	prefab, ok := e.Prefabs[prefabName]
+
+	if !ok {
+		log.Printf("Prefab %s does not exist\n", prefabName)
+		return 0, false
+	}
+
+	// The spawned entity holds a reference of its own, so it is released like any other entity
+	pImage, pTexture, err := e.Textures.Acquire(prefab.Path)
+
+	if err != nil {
+		log.Printf("Not able to load image %s of prefab %s\n", prefab.Path, prefabName)
+		return 0, false
+	}
+
+	entityID := e.CreateEntity(prefab.Components)
+
+	pRCD := e.GetComponentDataByName(entityID, "RENDER_COMPONENT").(*RenderComponentData)
+	pRCD.Path = prefab.Path
+	pRCD.Image = pImage
+	pRCD.Texture = pTexture
+	pRCD.Layer = e.DefaultRenderLayer(prefab.Components)
+
+	pTCD := e.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+	pTCD.PosX = posX
+	pTCD.PosY = posY
+	pTCD.LastPosX = posX
+	pTCD.LastPosY = posY
+
+	if prefab.Pickup != nil && e.HasNamedComponent(prefab.Components, "PICKUP_COMPONENT") {
+		*e.GetComponentDataByName(entityID, "PICKUP_COMPONENT").(*PickupComponentData) = *prefab.Pickup
+	}
+
+	return entityID, true
+}
+
+// MarkEntityForRemoval schedules the entity to be removed by RemoveMarkedEntities
+func (e *ECSManager) MarkEntityForRemoval(entityID uint64) {
+	e.entitiesToRemove[entityID] = true
+}
+
+func (e *ECSManager) IsMarkedForRemoval(entityID uint64) bool {
+	return e.entitiesToRemove[entityID]
+}
+
+// RemoveMarkedEntities removes all marked entities together with their component data.
+// It must not be called while a system iterates over the entities.
+func (e *ECSManager) RemoveMarkedEntities() {
+	if len(e.entitiesToRemove) == 0 {
+		return
+	}
 
-			keyForEntityComponentDataMap = entityIDStr + "-" + componentIDStr
-			cd := ComponentData{Data: nil}
-
-			// Only link data if entityComponentMap map's val for that key is nil
-			// Also only link data if a real component exists.
-
-			if keyForEntityComponentDataMap != "-" && j != 65535 {
-				switch uint16(j) {
-				case e.ComponentIDStorage["TRANSFORM_COMPONENT"]:
-					cd.Data = &TransformComponentData{}
-				case e.ComponentIDStorage["RENDER_COMPONENT"]:
-					cd.Data = &RenderComponentData{}
-				case e.ComponentIDStorage["ANIMATE_COMPONENT"]:
-					acd := make(map[string]*AnimationComponentDataCore)
-					cd.Data = &AnimateComponentData{AnimationData: &acd}
-				case e.ComponentIDStorage["GRAVITY_COMPONENT"]:
-					cd.Data = &GravityComponentData{}
-				case e.ComponentIDStorage["SIDE_SCROLL_COMPONENT"]:
-					cd.Data = &SideScrollComponentData{sidescrolled: &sideScrollCounterX, hspeed: 5.0}
-				case e.ComponentIDStorage["COLLIDE_COMPONENT"]:
-					collisionCoreData := &CollisionCoreData{CollisionDirection: make(map[string]bool), LastCollisionDirection: make(map[string]bool)}
-					collisionCoreData.CollisionDirection["bottom"] = true
-					collisionCoreData.LastCollisionDirection["bottom"] = true
-					cd.Data = &CollisionComponentData{collisionCoreData}
-				}
+	e.entityMu.Lock()
+	defer e.entityMu.Unlock()
+
+	for entityID := range e.entitiesToRemove {
+		componentMap, ok := e.EntityToComponentMap.Get(entityID)
+
+		if !ok {
+			continue
+		}
+
+		e.releaseEntityImages(entityID)
+
+		for componentID := range componentMap.([]uint16) {
+			delete(e.EntityComponentStringToComponentDataMap, strconv.Itoa(int(entityID))+"-"+strconv.Itoa(componentID))
+		}
+
+		e.EntityToComponentMap.Delete(entityID)
+	}
+
+	e.entitiesToRemove = make(map[uint64]bool)
+	e.events = nil
+}
+
+// releaseEntityImages gives the images of an entity back to the texture cache. Animated entities
+// hold a reference to every frame and just borrow the current one for rendering, texts are
+// rendered for the entity alone and are freed right away.
+func (e *ECSManager) releaseEntityImages(entityID uint64) {
+	pRCD := e.GetComponentDataByName(entityID, "RENDER_COMPONENT").(*RenderComponentData)
+	pACD := e.GetComponentDataByName(entityID, "ANIMATE_COMPONENT").(*AnimateComponentData)
+
+	if pRCD.Text != nil {
+		pRCD.Text.Free()
+
+		if pRCD.Texture != nil {
+			_ = pRCD.Texture.Destroy()
+		}
+		return
+	}
+
+	e.releaseTilemap(e.GetComponentDataByName(entityID, "TILEMAP_COMPONENT").(*TilemapComponentData))
+
+	if len(*pACD.AnimationData) > 0 {
+		for _, pACDCore := range *pACD.AnimationData {
+			for _, path := range pACDCore.Paths {
+				e.Textures.Release(path)
 			}
-			entityComponentStringToComponentDataMap[keyForEntityComponentDataMap] = &cd
 		}
+		return
+	}
+
+	if pRCD.Image != nil {
+		e.Textures.Release(pRCD.Path)
 	}
 }
 
+// ResourceStats counts the SDL resources that are alive right now
+type ResourceStats struct {
+	// Images and their textures held by the texture cache
+	Images int
+	// Rendered texts, each with a texture of its own
+	Texts int
+	Fonts int
+	// Baked tilemap chunks
+	Chunks int
+}
+
+// LiveResources counts the SDL resources that have not been freed yet. ClearEntities
+// frees everything but the fonts, they stay open until the FontCache is closed.
+func (e *ECSManager) LiveResources() ResourceStats {
+	stats := ResourceStats{
+		Images: e.Textures.Stats().Entries,
+		Fonts:  e.Fonts.Len(),
+	}
+
+	for el := e.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		if e.GetComponentDataByName(el.Key.(uint64), "RENDER_COMPONENT").(*RenderComponentData).Text != nil {
+			stats.Texts++
+		}
+
+		stats.Chunks += len(e.GetComponentDataByName(el.Key.(uint64), "TILEMAP_COMPONENT").(*TilemapComponentData).chunks)
+	}
+
+	return stats
+}
+
+// Lock keeps the render goroutine away from the entities, e.g. while a level is replaced
+func (e *ECSManager) Lock() {
+	e.entityMu.Lock()
+}
+
+func (e *ECSManager) Unlock() {
+	e.entityMu.Unlock()
+}
+
+// ClearEntities removes all entities and releases the images and textures they were using,
+// including the prefabs. The caller must hold the Lock.
+func (e *ECSManager) ClearEntities() {
+	for el := e.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		e.releaseEntityImages(el.Key.(uint64))
+	}
+
+	for _, prefab := range e.Prefabs {
+		e.Textures.Release(prefab.Path)
+	}
+
+	for _, layer := range e.ParallaxLayers {
+		e.Textures.Release(layer.Path)
+	}
+
+	e.Prefabs = make(map[string]*Prefab)
+	e.LootTables = make(map[string][]LootDrop)
+	e.ParallaxLayers = nil
+	e.EntityToComponentMap = orderedmap.NewOrderedMap()
+	e.EntityComponentStringToComponentDataMap = make(map[string]*ComponentData)
+	e.entitiesToRemove = make(map[uint64]bool)
+	e.events = nil
+}
+
 func (e *ECSManager) GetEntityIDBoundariesFromEntityRange(entityIDStr string) *[2]uint64 {
 	// Does not necessarily need to be a receiver function/method
 	// See with time which choice is better
@@ -241,14 +538,16 @@ func (e *ECSManager) GetEntityRect(entityID uint64) *sdl.Rect {
 	pRCD := e.GetComponentDataByName(entityID, "RENDER_COMPONENT").(*RenderComponentData)
 	pTCD := e.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
 
-	h := pRCD.Image.H
-	w := pRCD.Image.W
+	w, h := pRCD.Size()
 
 	return &sdl.Rect{X: int32(pTCD.PosX), Y: int32(pTCD.PosY), W: w, H: h}
 }
 
 // Systems
 
+// MillisecondsPerTick is the duration of one tick, systems get a delta of 1.0 per tick
+const MillisecondsPerTick = 1000.0 / 70.0
+
 type CommonSystemData struct {
 	SystemID   uint16
 	ECSManager *ECSManager
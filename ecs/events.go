@@ -0,0 +1,26 @@
+package ecs
+
+type EventType uint8
+
+const (
+	ENTITY_DIED EventType = iota
+	// EntityID is the goal the player reached
+	LEVEL_COMPLETED
+)
+
+// Event is something systems want the game to react to after all systems ran
+type Event struct {
+	Type     EventType
+	EntityID uint64
+}
+
+func (e *ECSManager) EmitEvent(event Event) {
+	e.events = append(e.events, event)
+}
+
+// PollEvents returns all events emitted since the last call and forgets them
+func (e *ECSManager) PollEvents() []Event {
+	events := e.events
+	e.events = nil
+	return events
+}
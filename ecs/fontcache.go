@@ -0,0 +1,54 @@
+package ecs
+
+import (
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+type fontKey struct {
+	path string
+	size int
+}
+
+// FontCache opens every font file in every size once. Fonts are small,
+// so they stay open until the cache is closed when the game shuts down.
+type FontCache struct {
+	fonts map[fontKey]*ttf.Font
+}
+
+func NewFontCache() *FontCache {
+	return &FontCache{
+		fonts: make(map[fontKey]*ttf.Font),
+	}
+}
+
+// Get returns the font at path in the given size, opening it if needed
+func (c *FontCache) Get(path string, size int) (*ttf.Font, error) {
+	key := fontKey{path: path, size: size}
+
+	if font, ok := c.fonts[key]; ok {
+		return font, nil
+	}
+
+	font, err := ttf.OpenFont(path, size)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.fonts[key] = font
+
+	return font, nil
+}
+
+// Len is the number of open fonts
+func (c *FontCache) Len() int {
+	return len(c.fonts)
+}
+
+// Close closes all fonts, fonts returned by Get must not be used afterwards
+func (c *FontCache) Close() {
+	for key, font := range c.fonts {
+		font.Close()
+		delete(c.fonts, key)
+	}
+}
@@ -0,0 +1,60 @@
+package ecs
+
+import (
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+type GoalComponentData struct {
+	PlayerInside bool
+}
+
+// GoalSystem completes the level when the player steps into a goal.
+// Whether the next level may be entered is up to the game, so a player
+// who is turned away can come back later and try again.
+type GoalSystem struct {
+	*CommonSystemData
+}
+
+func NewGoalSystem(e *ECSManager) *GoalSystem {
+	return &GoalSystem{
+		CommonSystemData: NewCommonSystemData("GOAL_COMPONENT", e),
+	}
+}
+
+func (sys *GoalSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	playerID, hasPlayer := ecsManager.FindPlayer()
+
+	if !hasPlayer {
+		return
+	}
+
+	playerRect := ecsManager.GetEntityRect(playerID)
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) {
+			continue
+		}
+
+		pGCD := sys.GetComponentData(entityID).(*GoalComponentData)
+
+		sys.UpdateComponent(delta, entityID, pGCD, playerRect.HasIntersection(ecsManager.GetEntityRect(entityID)))
+	}
+}
+
+func (sys *GoalSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	entityID := essentialData[0].(uint64)
+	pGCD := essentialData[1].(*GoalComponentData)
+	playerInside := essentialData[2].(bool)
+
+	if playerInside && !pGCD.PlayerInside {
+		sys.ECSManager.EmitEvent(Event{Type: LEVEL_COMPLETED, EntityID: entityID})
+	}
+
+	pGCD.PlayerInside = playerInside
+}
@@ -0,0 +1,148 @@
+package ecs
+
+import (
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+// HealthComponentData keeps track of an entity's hit points.
+// All times are in milliseconds, knockbacks are speeds in pixels per tick.
+type HealthComponentData struct {
+	MaxHP               int32
+	HP                  int32
+	InvulnerabilityTime float64
+	HurtTime            float64
+	BlinkInterval       float64
+	KnockbackX          int32
+	KnockbackY          int32
+
+	IsHurt bool
+	IsDead bool
+
+	invulnerableLeft float64
+	hurtLeft         float64
+}
+
+// DamageComponentData makes an entity hurt everything with health that touches it (hazards, enemies)
+type DamageComponentData struct {
+	Damage int32
+}
+
+func (hcd *HealthComponentData) IsInvulnerable() bool {
+	return hcd.invulnerableLeft > 0
+}
+
+// DamageEntity subtracts damage from the target's hit points, knocks it away from sourceX
+// and makes it invulnerable for a while. It returns false if the target could not be hurt.
+func (e *ECSManager) DamageEntity(target uint64, damage int32, sourceX int32) bool {
+	components, ok := e.EntityToComponentMap.Get(target)
+
+	if !ok || !e.HasNamedComponent(components.([]uint16), "HEALTH_COMPONENT") {
+		return false
+	}
+
+	pHCD := e.GetComponentDataByName(target, "HEALTH_COMPONENT").(*HealthComponentData)
+
+	if pHCD.IsDead || pHCD.IsInvulnerable() {
+		return false
+	}
+
+	pHCD.HP -= damage
+
+	if pHCD.HP < 0 {
+		pHCD.HP = 0
+	}
+
+	pHCD.IsHurt = true
+	pHCD.hurtLeft = pHCD.HurtTime
+	pHCD.invulnerableLeft = pHCD.InvulnerabilityTime
+
+	if e.HasNamedComponent(components.([]uint16), "TRANSFORM_COMPONENT") {
+		pTCD := e.GetComponentDataByName(target, "TRANSFORM_COMPONENT").(*TransformComponentData)
+		targetRect := e.GetEntityRect(target)
+
+		if targetRect.X+targetRect.W/2 < sourceX {
+			pTCD.Hspeed = -pHCD.KnockbackX
+		} else {
+			pTCD.Hspeed = pHCD.KnockbackX
+		}
+
+		if pHCD.KnockbackY != 0 {
+			pTCD.Vspeed = pHCD.KnockbackY
+			pTCD.IsJumping = true
+		}
+	}
+
+	return true
+}
+
+// Heal gives the entity hit points back, never more than its maximum
+func (e *ECSManager) Heal(target uint64, hp int32) {
+	pHCD := e.GetComponentDataByName(target, "HEALTH_COMPONENT").(*HealthComponentData)
+
+	pHCD.HP += hp
+
+	if pHCD.HP > pHCD.MaxHP {
+		pHCD.HP = pHCD.MaxHP
+	}
+}
+
+type HealthSystem struct {
+	*CommonSystemData
+}
+
+func NewHealthSystem(e *ECSManager) *HealthSystem {
+	return &HealthSystem{
+		CommonSystemData: NewCommonSystemData("HEALTH_COMPONENT", e),
+	}
+}
+
+func (sys *HealthSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) {
+			continue
+		}
+
+		pHCD := sys.GetComponentData(entityID).(*HealthComponentData)
+		pRCD := ecsManager.GetComponentDataByName(entityID, "RENDER_COMPONENT").(*RenderComponentData)
+
+		sys.UpdateComponent(delta, entityID, pHCD, pRCD)
+	}
+}
+
+func (sys *HealthSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	entityID := essentialData[0].(uint64)
+	pHCD := essentialData[1].(*HealthComponentData)
+	pRCD := essentialData[2].(*RenderComponentData)
+
+	elapsedMs := delta * MillisecondsPerTick
+
+	if pHCD.HP <= 0 && !pHCD.IsDead {
+		pHCD.IsDead = true
+		sys.ECSManager.EmitEvent(Event{Type: ENTITY_DIED, EntityID: entityID})
+	}
+
+	if pHCD.IsHurt {
+		pHCD.hurtLeft -= elapsedMs
+		pHCD.IsHurt = pHCD.hurtLeft > 0
+	}
+
+	if !pHCD.IsInvulnerable() {
+		pRCD.Hidden = false
+		return
+	}
+
+	pHCD.invulnerableLeft -= elapsedMs
+
+	// Blink: hidden every other interval until invulnerability is over
+	if pHCD.BlinkInterval > 0 && pHCD.IsInvulnerable() {
+		pRCD.Hidden = int(pHCD.invulnerableLeft/pHCD.BlinkInterval)%2 == 1
+	} else {
+		pRCD.Hidden = false
+	}
+}
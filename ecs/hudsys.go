@@ -0,0 +1,130 @@
+package ecs
+
+import (
+	"fmt"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+// HudComponentData binds a text to values of the running game
+type HudComponentData struct {
+	// fmt format of the text, with one verb per value
+	Format string
+	// Names of the shown values, see HudValueNames
+	Values []string
+}
+
+// HudValueNames are the values a HUD element can show. Time is a string like "1:05",
+// all others are integers.
+var HudValueNames = map[string]bool{
+	"Health":    true,
+	"MaxHealth": true,
+	"Lives":     true,
+	"Score":     true,
+	"Coins":     true,
+	"Ammo":      true,
+	"Keys":      true,
+	"Time":      true,
+}
+
+// HudSystem keeps the texts of the HUD up to date and counts the time spent in the level.
+// HUD entities have no REAL_COMPONENT, so they stay where they are when the camera moves.
+type HudSystem struct {
+	*CommonSystemData
+}
+
+func NewHudSystem(e *ECSManager) *HudSystem {
+	return &HudSystem{
+		CommonSystemData: NewCommonSystemData("HUD_COMPONENT", e),
+	}
+}
+
+func (sys *HudSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	ecsManager.LevelTime += delta * MillisecondsPerTick
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) || !ecsManager.HasNamedComponent(components, "TEXT_COMPONENT") {
+			continue
+		}
+
+		pHUDCD := sys.GetComponentData(entityID).(*HudComponentData)
+		pTXCD := ecsManager.GetComponentDataByName(entityID, "TEXT_COMPONENT").(*TextComponentData)
+
+		sys.UpdateComponent(delta, pHUDCD, pTXCD)
+	}
+}
+
+func (sys *HudSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	pHUDCD := essentialData[0].(*HudComponentData)
+	pTXCD := essentialData[1].(*TextComponentData)
+
+	// The TextSystem only renders the text again if this changed it
+	pTXCD.Content = sys.ECSManager.hudText(pHUDCD)
+}
+
+// UpdateHud sets the texts of all HUD entities to the current values
+func (e *ECSManager) UpdateHud() {
+	for el := e.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !e.HasNamedComponent(components, "HUD_COMPONENT") || !e.HasNamedComponent(components, "TEXT_COMPONENT") {
+			continue
+		}
+
+		pHUDCD := e.GetComponentDataByName(entityID, "HUD_COMPONENT").(*HudComponentData)
+		pTXCD := e.GetComponentDataByName(entityID, "TEXT_COMPONENT").(*TextComponentData)
+
+		pTXCD.Content = e.hudText(pHUDCD)
+	}
+}
+
+func (e *ECSManager) hudText(pHUDCD *HudComponentData) string {
+	values := make([]interface{}, len(pHUDCD.Values))
+
+	for i, name := range pHUDCD.Values {
+		values[i] = e.hudValue(name)
+	}
+
+	return fmt.Sprintf(pHUDCD.Format, values...)
+}
+
+func (e *ECSManager) hudValue(name string) interface{} {
+	inventory := e.Inventory
+
+	switch name {
+	case "Health", "MaxHealth":
+		playerID, hasPlayer := e.FindPlayer()
+
+		if !hasPlayer {
+			return int32(0)
+		}
+
+		pHCD := e.GetComponentDataByName(playerID, "HEALTH_COMPONENT").(*HealthComponentData)
+
+		if name == "MaxHealth" {
+			return pHCD.MaxHP
+		}
+		return pHCD.HP
+	case "Lives":
+		return inventory.Lives
+	case "Score":
+		return inventory.Score
+	case "Coins":
+		return inventory.Coins
+	case "Ammo":
+		return inventory.Ammo
+	case "Keys":
+		return int32(len(inventory.Keys))
+	case "Time":
+		seconds := int(e.LevelTime / 1000)
+		return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+	}
+	return nil
+}
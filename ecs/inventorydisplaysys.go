@@ -0,0 +1,45 @@
+package ecs
+
+import (
+	"fmt"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+// InventoryDisplaySystem keeps the text of entities with an INVENTORY_DISPLAY_COMPONENT
+// in sync with the player's score and lives. The TextSystem renders the text again
+// only when one of them changed.
+type InventoryDisplaySystem struct {
+	*CommonSystemData
+}
+
+func NewInventoryDisplaySystem(e *ECSManager) *InventoryDisplaySystem {
+	return &InventoryDisplaySystem{
+		CommonSystemData: NewCommonSystemData("INVENTORY_DISPLAY_COMPONENT", e),
+	}
+}
+
+func (sys *InventoryDisplaySystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) || !ecsManager.HasNamedComponent(components, "TEXT_COMPONENT") {
+			continue
+		}
+
+		pTXCD := ecsManager.GetComponentDataByName(entityID, "TEXT_COMPONENT").(*TextComponentData)
+
+		sys.UpdateComponent(delta, pTXCD, ecsManager.Inventory)
+	}
+}
+
+func (sys *InventoryDisplaySystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	pTXCD := essentialData[0].(*TextComponentData)
+	inventory := essentialData[1].(*Inventory)
+
+	pTXCD.Content = fmt.Sprintf("Score: %d Lives: %d", inventory.Score, inventory.Lives)
+}
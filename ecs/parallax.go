@@ -0,0 +1,65 @@
+package ecs
+
+import (
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// ParallaxLayer is a background image drawn behind all entities. It moves by its scroll factor
+// times the distance the camera moved, so layers with small factors seem to be far away.
+type ParallaxLayer struct {
+	Path          string
+	Image         *sdl.Surface
+	Texture       *sdl.Texture
+	ScrollFactorX float64
+	ScrollFactorY float64
+	// Repeated layers are tiled along that axis so they never run out
+	RepeatX bool
+	RepeatY bool
+	OffsetY int32
+}
+
+// renderParallaxLayers draws the level's parallax layers, the first one furthest back
+func (sys *RenderSystem) renderParallaxLayers(cameraX, cameraY int32) {
+	viewWidth, viewHeight := ViewSize(sys.Renderer)
+
+	for _, layer := range sys.ECSManager.ParallaxLayers {
+		w := layer.Image.W
+		h := layer.Image.H
+
+		originX := -int32(math.Round(float64(cameraX) * layer.ScrollFactorX))
+		originY := layer.OffsetY - int32(math.Round(float64(cameraY)*layer.ScrollFactorY))
+
+		firstX, lastX := originX, originX
+		firstY, lastY := originY, originY
+
+		if layer.RepeatX {
+			firstX, lastX = tileRange(originX, w, viewWidth)
+		}
+
+		if layer.RepeatY {
+			firstY, lastY = tileRange(originY, h, viewHeight)
+		}
+
+		for y := firstY; y <= lastY; y += h {
+			for x := firstX; x <= lastX; x += w {
+				_ = sys.Renderer.Copy(layer.Texture, nil, &sdl.Rect{X: x, Y: y, W: w, H: h})
+			}
+		}
+	}
+}
+
+// tileRange returns the positions of the first and the last tile of size tileSize
+// that are needed to cover 0 to viewSize when one tile starts at origin
+func tileRange(origin, tileSize, viewSize int32) (int32, int32) {
+	first := origin % tileSize
+
+	if first > 0 {
+		first -= tileSize
+	}
+
+	last := first + ((viewSize-first-1)/tileSize)*tileSize
+
+	return first, last
+}
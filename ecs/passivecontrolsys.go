@@ -0,0 +1,118 @@
+package ecs
+
+import (
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	BEHAVIOUR_IDLE   = "Idle"
+	BEHAVIOUR_PATROL = "Patrol"
+)
+
+// PassiveControlComponentData describes how an NPC moves on its own.
+// Speed and JumpSpeed are in pixels per tick, Direction is -1 (left) or 1 (right).
+type PassiveControlComponentData struct {
+	Behaviour     string
+	Speed         int32
+	TurnAtLedges  bool
+	JumpObstacles bool
+	JumpSpeed     int32
+	Direction     int32
+
+	// Where the last jump over an obstacle started, to give up when it was too high
+	lastJumpPosX int32
+	hasJumped    bool
+}
+
+type PassiveControlSystem struct {
+	*CommonSystemData
+}
+
+func NewPassiveControlSystem(e *ECSManager) *PassiveControlSystem {
+	return &PassiveControlSystem{
+		CommonSystemData: NewCommonSystemData("PASSIVE_CONTROL_COMPONENT_NPC", e),
+	}
+}
+
+func (sys *PassiveControlSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) || !ecsManager.HasNamedComponent(components, "TRANSFORM_COMPONENT") {
+			continue
+		}
+
+		pPCCD := sys.GetComponentData(entityID).(*PassiveControlComponentData)
+		pTCD := ecsManager.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+		pCCD := ecsManager.GetComponentDataByName(entityID, "COLLIDE_COMPONENT").(*CollisionComponentData)
+		pHCD := ecsManager.GetComponentDataByName(entityID, "HEALTH_COMPONENT").(*HealthComponentData)
+
+		if pHCD.IsHurt || pHCD.IsDead {
+			// Let the knockback play out
+			continue
+		}
+
+		sys.UpdateComponent(delta, entityID, pPCCD, pTCD, pCCD)
+	}
+}
+
+func (sys *PassiveControlSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	entityID := essentialData[0].(uint64)
+	pPCCD := essentialData[1].(*PassiveControlComponentData)
+	pTCD := essentialData[2].(*TransformComponentData)
+	pCCD := essentialData[3].(*CollisionComponentData)
+
+	switch pPCCD.Behaviour {
+	case BEHAVIOUR_PATROL:
+		sys.patrol(entityID, pPCCD, pTCD, pCCD)
+	default:
+		pTCD.Hspeed = 0
+	}
+
+	pTCD.IsNotMoving = pTCD.Hspeed == 0
+}
+
+// patrol walks back and forth, turning around at walls and, if wanted, at ledges.
+// Obstacles are jumped over if the NPC is allowed to and did not already fail at that spot.
+func (sys *PassiveControlSystem) patrol(entityID uint64, pPCCD *PassiveControlComponentData, pTCD *TransformComponentData, pCCD *CollisionComponentData) {
+	if pPCCD.Direction == 0 {
+		pPCCD.Direction = 1
+	}
+
+	rect := sys.ECSManager.GetEntityRect(entityID)
+
+	if pCCD.OnGround {
+		// Probe a step ahead, leaving out the feet which always touch the ground
+		ahead := &sdl.Rect{X: rect.X + pPCCD.Direction*pPCCD.Speed, Y: rect.Y, W: rect.W, H: rect.H - 4}
+		blocked := sys.ECSManager.SolidAt(ahead, entityID)
+
+		if blocked && pPCCD.JumpObstacles && !(pPCCD.hasJumped && pPCCD.lastJumpPosX == pTCD.PosX) {
+			pPCCD.hasJumped = true
+			pPCCD.lastJumpPosX = pTCD.PosX
+			pTCD.Vspeed = pPCCD.JumpSpeed
+			pTCD.IsJumping = true
+		} else if blocked {
+			pPCCD.Direction *= -1
+		} else if pPCCD.TurnAtLedges {
+			// A small probe just below the front foot
+			frontX := rect.X + rect.W
+			if pPCCD.Direction < 0 {
+				frontX = rect.X - 2
+			}
+
+			below := &sdl.Rect{X: frontX, Y: rect.Y + rect.H + 1, W: 2, H: 4}
+
+			if !sys.ECSManager.SolidAt(below, entityID) {
+				pPCCD.Direction *= -1
+			}
+		}
+	}
+
+	pTCD.Hspeed = pPCCD.Direction * pPCCD.Speed
+	pTCD.FlipImg = pPCCD.Direction < 0
+}
@@ -0,0 +1,119 @@
+package ecs
+
+import (
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+const (
+	PICKUP_COIN   = "Coin"
+	PICKUP_HEALTH = "Health"
+	PICKUP_AMMO   = "Ammo"
+	PICKUP_KEY    = "Key"
+)
+
+// PickupComponentData is something the player collects by touching it.
+// Value is the number of points, hit points or bullets it is worth,
+// KeyName identifies keys.
+type PickupComponentData struct {
+	Kind    string
+	Value   int32
+	KeyName string
+}
+
+// StartingLives is how often the player may die in a run before the game is over
+const StartingLives int32 = 3
+
+// Inventory is what the player collected during the current run
+type Inventory struct {
+	Score int32
+	Lives int32
+	Coins int32
+	Ammo  int32
+	Keys  map[string]bool
+	// Pickups collected per kind, for the end-of-level summary
+	Collected map[string]int32
+}
+
+func NewInventory() *Inventory {
+	return &Inventory{
+		Lives:     StartingLives,
+		Keys:      make(map[string]bool),
+		Collected: make(map[string]int32),
+	}
+}
+
+type PickupSystem struct {
+	*CommonSystemData
+}
+
+func NewPickupSystem(e *ECSManager) *PickupSystem {
+	return &PickupSystem{
+		CommonSystemData: NewCommonSystemData("PICKUP_COMPONENT", e),
+	}
+}
+
+func (sys *PickupSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	playerID, hasPlayer := ecsManager.FindPlayer()
+
+	if !hasPlayer {
+		return
+	}
+
+	playerRect := ecsManager.GetEntityRect(playerID)
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) || ecsManager.IsMarkedForRemoval(entityID) {
+			continue
+		}
+
+		if !playerRect.HasIntersection(ecsManager.GetEntityRect(entityID)) {
+			continue
+		}
+
+		pPUCD := sys.GetComponentData(entityID).(*PickupComponentData)
+
+		sys.UpdateComponent(delta, entityID, pPUCD, playerID)
+	}
+}
+
+func (sys *PickupSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	entityID := essentialData[0].(uint64)
+	pPUCD := essentialData[1].(*PickupComponentData)
+	playerID := essentialData[2].(uint64)
+
+	inventory := sys.ECSManager.Inventory
+
+	switch pPUCD.Kind {
+	case PICKUP_COIN:
+		inventory.Coins++
+		inventory.Score += pPUCD.Value
+	case PICKUP_HEALTH:
+		playerComponents, _ := sys.ECSManager.EntityToComponentMap.Get(playerID)
+
+		if !sys.ECSManager.HasNamedComponent(playerComponents.([]uint16), "HEALTH_COMPONENT") {
+			return
+		}
+
+		pHCD := sys.ECSManager.GetComponentDataByName(playerID, "HEALTH_COMPONENT").(*HealthComponentData)
+
+		if pHCD.HP >= pHCD.MaxHP {
+			// Leave it for later
+			return
+		}
+
+		sys.ECSManager.Heal(playerID, pPUCD.Value)
+	case PICKUP_AMMO:
+		inventory.Ammo += pPUCD.Value
+	case PICKUP_KEY:
+		inventory.Keys[pPUCD.KeyName] = true
+	}
+
+	inventory.Collected[pPUCD.Kind]++
+	sys.ECSManager.MarkEntityForRemoval(entityID)
+}
@@ -0,0 +1,123 @@
+package ecs
+
+import (
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+type ProjectileComponentData struct {
+	Owner  uint64
+	Damage int32
+	// Milliseconds until the projectile disappears on its own
+	LifetimeLeft float64
+}
+
+// Weapon describes the projectiles an entity shoots.
+// Lifetime and FireRate are in milliseconds, Speed is in pixels per tick.
+type Weapon struct {
+	Projectile string
+	Speed      float64
+	Lifetime   float64
+	FireRate   float64
+	Damage     int32
+	// Shots take ammo from the player's inventory
+	UsesAmmo bool
+
+	msSinceLastShot float64
+}
+
+// Reload lets the time between two shots pass
+func (w *Weapon) Reload(delta float64) {
+	w.msSinceLastShot += delta * MillisecondsPerTick
+}
+
+// Fire spawns a projectile next to the owner in the direction the owner is facing.
+// It returns false if the weapon is not ready to fire again yet.
+func (w *Weapon) Fire(e *ECSManager, owner uint64) bool {
+	if w.msSinceLastShot < w.FireRate {
+		return false
+	}
+
+	if w.UsesAmmo && e.Inventory.Ammo <= 0 {
+		return false
+	}
+
+	prefab, ok := e.Prefabs[w.Projectile]
+
+	if !ok {
+		return false
+	}
+
+	ownerRect := e.GetEntityRect(owner)
+	pTCDOwner := e.GetComponentDataByName(owner, "TRANSFORM_COMPONENT").(*TransformComponentData)
+
+	posX := ownerRect.X + ownerRect.W
+	posY := ownerRect.Y + ownerRect.H/2 - prefab.Image.H/2
+	hspeed := int32(w.Speed)
+
+	if pTCDOwner.FlipImg {
+		posX = ownerRect.X - prefab.Image.W
+		hspeed = -hspeed
+	}
+
+	projectileID, ok := e.SpawnPrefab(w.Projectile, posX, posY)
+
+	if !ok {
+		return false
+	}
+
+	pTCD := e.GetComponentDataByName(projectileID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+	pTCD.Hspeed = hspeed
+	pTCD.FlipImg = pTCDOwner.FlipImg
+
+	pPCD := e.GetComponentDataByName(projectileID, "PROJECTILE_COMPONENT").(*ProjectileComponentData)
+	pPCD.Owner = owner
+	pPCD.Damage = w.Damage
+	pPCD.LifetimeLeft = w.Lifetime
+
+	w.msSinceLastShot = 0
+
+	if w.UsesAmmo {
+		e.Inventory.Ammo--
+	}
+
+	return true
+}
+
+type ProjectileSystem struct {
+	*CommonSystemData
+}
+
+func NewProjectileSystem(e *ECSManager) *ProjectileSystem {
+	return &ProjectileSystem{
+		CommonSystemData: NewCommonSystemData("PROJECTILE_COMPONENT", e),
+	}
+}
+
+func (sys *ProjectileSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) {
+			continue
+		}
+
+		pPCD := sys.GetComponentData(entityID).(*ProjectileComponentData)
+
+		sys.UpdateComponent(delta, entityID, pPCD)
+	}
+}
+
+func (sys *ProjectileSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	entityID := essentialData[0].(uint64)
+	pPCD := essentialData[1].(*ProjectileComponentData)
+
+	pPCD.LifetimeLeft -= delta * MillisecondsPerTick
+
+	if pPCD.LifetimeLeft <= 0 {
+		sys.ECSManager.MarkEntityForRemoval(entityID)
+	}
+}
@@ -1,39 +1,119 @@
 package ecs
 
 import (
+	"log"
+	"sort"
+	"sync"
+
 	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
 	"github.com/veandco/go-sdl2/sdl"
-	"sync"
 )
 
+// RenderLayer decides what is drawn over what, entities of higher layers are drawn later
+type RenderLayer uint8
+
+const (
+	LAYER_BACKGROUND RenderLayer = iota
+	LAYER_TERRAIN
+	LAYER_ACTORS
+	LAYER_FOREGROUND
+	LAYER_UI
+)
+
+// RenderLayerNames maps the layer names used in the level descriptions to the layers
+var RenderLayerNames = map[string]RenderLayer{
+	"Background": LAYER_BACKGROUND,
+	"Terrain":    LAYER_TERRAIN,
+	"Actors":     LAYER_ACTORS,
+	"Foreground": LAYER_FOREGROUND,
+	"UI":         LAYER_UI,
+}
+
 type RenderComponentData struct {
-	Path     string
-	Image    *sdl.Surface
-	Texture  *sdl.Texture
-	Text     *sdl.Surface
-	FontSize uint8
+	Path      string
+	Image     *sdl.Surface
+	Texture   *sdl.Texture
+	Text      *sdl.Surface
+	TextAlign TextAlign
+	FontSize  uint8
+	// Set if Image is an atlas and only this frame of it is shown
+	Frame *AtlasFrame
+	// Hidden entities are skipped when drawing, e.g. to let them blink
+	Hidden bool
+	Layer  RenderLayer
+	// Order inside the layer, entities with the same ZIndex are drawn in entity ID order
+	ZIndex int32
+}
+
+// Size is the size of the entity on the screen, for atlas frames the untrimmed size
+func (pRCD *RenderComponentData) Size() (int32, int32) {
+	if pRCD.Frame != nil {
+		return pRCD.Frame.SourceW, pRCD.Frame.SourceH
+	}
+
+	if pRCD.Text != nil {
+		return pRCD.Text.W, pRCD.Text.H
+	}
+
+	if pRCD.Image == nil {
+		return 0, 0
+	}
+
+	return pRCD.Image.W, pRCD.Image.H
+}
+
+// renderItem is an entity that is visible this frame together with where it is drawn
+type renderItem struct {
+	pRCD    *RenderComponentData
+	pTCD    *TransformComponentData
+	dstRect *sdl.Rect
+	// Only set for tilemaps, which are drawn by the tilemapRenderer
+	pTMCD *TilemapComponentData
 }
 
 type RenderSystem struct {
 	*CommonSystemData
 	Renderer *sdl.Renderer
-	mu *sync.Mutex
+	mu       *sync.Mutex
+	// Reused every frame, only touched while entityMu is held
+	renderItems []renderItem
+	tilemaps    *tilemapRenderer
+	// Where to save the next frame, guarded by mu
+	screenshotPath string
+	// Drawn over everything else, e.g. the pause text. Guarded by entityMu.
+	overlay func(renderer *sdl.Renderer, viewWidth, viewHeight int32)
+	debug   debugOverlay
 }
 
 func NewRenderSystem(e *ECSManager, renderer *sdl.Renderer) *RenderSystem {
 	return &RenderSystem{
 		CommonSystemData: NewCommonSystemData("RENDER_COMPONENT", e),
 		Renderer:         renderer,
-		mu: &sync.Mutex{},
+		mu:               &sync.Mutex{},
+		tilemaps:         &tilemapRenderer{renderer: renderer},
 	}
 }
 
 func (sys *RenderSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
 	ecsManager := sys.ECSManager
-	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	// Entities must not be added or removed while we draw them. The lock is held until the
+	// frame is presented, so a frame drawn by the next tick cannot clear or draw into this one.
+	ecsManager.entityMu.Lock()
+	defer ecsManager.entityMu.Unlock()
 
 	sys.Renderer.Clear()
 
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+	cameraX, cameraY := ecsManager.CameraPosition()
+
+	sys.renderParallaxLayers(cameraX, cameraY)
+
+	viewWidth, viewHeight := ViewSize(sys.Renderer)
+	viewport := &sdl.Rect{X: 0, Y: 0, W: viewWidth, H: viewHeight}
+
+	sys.renderItems = sys.renderItems[:0]
+
 	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
 		components := el.Value.([]uint16)
 		entityID := el.Key.(uint64)
@@ -44,44 +124,186 @@ func (sys *RenderSystem) Run(delta float64, statemachine *statemachine.StateMach
 
 		pRCD := sys.GetComponentData(entityID).(*RenderComponentData)
 		pTCD := sys.ECSManager.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
-		sys.UpdateComponent(delta, pRCD, pTCD)
+
+		if pRCD.Hidden {
+			continue
+		}
+
+		// Entities of the level are in world coordinates, everything else (backgrounds, texts) in screen coordinates
+		var offsetX, offsetY int32
+
+		if sys.ECSManager.HasNamedComponent(components, "REAL_COMPONENT") {
+			offsetX, offsetY = cameraX, cameraY
+		}
+
+		var pTMCD *TilemapComponentData
+
+		if sys.ECSManager.HasNamedComponent(components, "TILEMAP_COMPONENT") {
+			pTMCD = sys.ECSManager.GetComponentDataByName(entityID, "TILEMAP_COMPONENT").(*TilemapComponentData)
+		}
+
+		dstRect, drawable := destinationRect(pRCD, pTCD, pTMCD, offsetX, offsetY)
+
+		// Culling, most of the level is not on the screen
+		if !drawable || !dstRect.HasIntersection(viewport) {
+			continue
+		}
+
+		sys.renderItems = append(sys.renderItems, renderItem{pRCD: pRCD, pTCD: pTCD, dstRect: dstRect, pTMCD: pTMCD})
+	}
+
+	// Stable, so entity ID order is kept inside a layer and z-index
+	sort.SliceStable(sys.renderItems, func(i, j int) bool {
+		a, b := sys.renderItems[i].pRCD, sys.renderItems[j].pRCD
+
+		if a.Layer != b.Layer {
+			return a.Layer < b.Layer
+		}
+		return a.ZIndex < b.ZIndex
+	})
+
+	for _, item := range sys.renderItems {
+		if item.pTMCD != nil {
+			sys.tilemaps.draw(item.pTMCD, item.dstRect, viewport)
+			continue
+		}
+
+		sys.UpdateComponent(delta, item.pRCD, item.pTCD, item.dstRect)
+	}
+
+	sys.renderDebugOverlay(cameraX, cameraY, viewport)
+
+	if sys.overlay != nil {
+		sys.overlay(sys.Renderer, viewWidth, viewHeight)
+	}
+
+	// Always taken after entityMu, like Game.Shutdown does
+	sys.mu.Lock()
+
+	if sys.screenshotPath != "" {
+		if err := SaveScreenshot(sys.Renderer, sys.screenshotPath); err != nil {
+			log.Printf("Not able to save screenshot %s: %s\n", sys.screenshotPath, err)
+		} else {
+			log.Printf("Saved screenshot %s\n", sys.screenshotPath)
+		}
+
+		sys.screenshotPath = ""
 	}
 
-    sys.mu.Lock()
 	sys.Renderer.Present()
 	sys.mu.Unlock()
 }
 
+// ViewSize is the size of the area entities are drawn to. With a logical size set the
+// renderer scales it to the window, so it stays the same whatever size the window has.
+func ViewSize(renderer *sdl.Renderer) (int32, int32) {
+	if width, height := renderer.GetLogicalSize(); width > 0 && height > 0 {
+		return width, height
+	}
+
+	width, height, _ := renderer.GetOutputSize()
+
+	return width, height
+}
+
+// SetOverlay lets draw be called at the end of every frame, nil removes the overlay.
+// It waits for a frame that is still being drawn, which reads the overlay under the entity lock.
+func (sys *RenderSystem) SetOverlay(draw func(renderer *sdl.Renderer, viewWidth, viewHeight int32)) {
+	sys.ECSManager.entityMu.Lock()
+	sys.overlay = draw
+	sys.ECSManager.entityMu.Unlock()
+}
+
+// RequestScreenshot saves the next frame that is drawn to a PNG file at path
+func (sys *RenderSystem) RequestScreenshot(path string) {
+	sys.mu.Lock()
+	sys.screenshotPath = path
+	sys.mu.Unlock()
+}
+
+// Lock waits until the frame being drawn is presented and keeps further frames from being presented
+func (sys *RenderSystem) Lock() {
+	sys.mu.Lock()
+}
+
+func (sys *RenderSystem) Unlock() {
+	sys.mu.Unlock()
+}
+
 func (sys *RenderSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
 	pRCD := essentialData[0].(*RenderComponentData)
 	pTCD := essentialData[1].(*TransformComponentData)
+	dstRect := essentialData[2].(*sdl.Rect)
 
-	var img *sdl.Surface
-	var h int32
-	var w int32
-	var dstRect *sdl.Rect
-	var sdlFlip sdl.RendererFlip
-	texture := pRCD.Texture
+	sdlFlip := sdl.FLIP_NONE
+
+	if pRCD.Text == nil && pTCD.FlipImg {
+		sdlFlip = sdl.FLIP_HORIZONTAL
+	}
+
+	var srcRect *sdl.Rect
+
+	if pRCD.Frame != nil {
+		srcRect = &pRCD.Frame.Src
+	}
+
+	sys.Renderer.CopyEx(pRCD.Texture, srcRect, dstRect, 0.0, nil, sdlFlip)
+}
+
+// destinationRect is where on the screen the entity is drawn. It returns false
+// if the entity has nothing to draw. Tilemaps are drawn over their whole size.
+func destinationRect(pRCD *RenderComponentData, pTCD *TransformComponentData, pTMCD *TilemapComponentData, offsetX, offsetY int32) (*sdl.Rect, bool) {
+	if pTMCD != nil {
+		width, height := pTMCD.Size()
+		return &sdl.Rect{X: pTCD.PosX - offsetX, Y: pTCD.PosY - offsetY, W: width, H: height}, width > 0 && height > 0
+	}
 
 	renderImage := pRCD.Image != nil && pRCD.Text == nil
 	renderText := pRCD.Image == nil && pRCD.Text != nil
 
-	if renderImage {
-		img = pRCD.Image
-		h = img.H
-		w = img.W
-		dstRect = &sdl.Rect{X: int32(pTCD.PosX), Y: int32(pTCD.PosY), W: w, H: h}
+	if renderImage && pRCD.Frame != nil {
+		// Trimmed frames are smaller than the entity, put them where they were before trimming
+		frame := pRCD.Frame
+		offsetInEntityX := frame.OffsetX
 
 		if pTCD.FlipImg {
-			sdlFlip = sdl.FLIP_HORIZONTAL
-		} else {
-			sdlFlip = sdl.FLIP_NONE
+			offsetInEntityX = frame.SourceW - frame.OffsetX - frame.Src.W
 		}
-	} else if renderText {
-		dstRect = &sdl.Rect{X: int32(pTCD.PosX), Y: int32(pTCD.PosY), W: 125, H: 25}
-	} else {
-		return
+
+		return &sdl.Rect{X: pTCD.PosX - offsetX + offsetInEntityX, Y: pTCD.PosY - offsetY + frame.OffsetY, W: frame.Src.W, H: frame.Src.H}, true
 	}
 
-	sys.Renderer.CopyEx(texture, nil, dstRect, 0.0, nil, sdlFlip)
+	if renderImage {
+		return &sdl.Rect{X: pTCD.PosX - offsetX, Y: pTCD.PosY - offsetY, W: pRCD.Image.W, H: pRCD.Image.H}, true
+	}
+
+	if renderText {
+		// Centered and right aligned texts grow to both sides or to the left when they change
+		alignOffset := int32(0)
+
+		switch pRCD.TextAlign {
+		case ALIGN_CENTER:
+			alignOffset = pRCD.Text.W / 2
+		case ALIGN_RIGHT:
+			alignOffset = pRCD.Text.W
+		}
+
+		return &sdl.Rect{X: pTCD.PosX - offsetX - alignOffset, Y: pTCD.PosY - offsetY, W: pRCD.Text.W, H: pRCD.Text.H}, true
+	}
+
+	return nil, false
+}
+
+// DefaultRenderLayer is the layer of entities the level description does not put on a layer
+func (e *ECSManager) DefaultRenderLayer(components []uint16) RenderLayer {
+	switch {
+	case !e.HasNamedComponent(components, "REAL_COMPONENT") && e.HasNamedComponent(components, "INVENTORY_DISPLAY_COMPONENT"):
+		return LAYER_UI
+	case !e.HasNamedComponent(components, "REAL_COMPONENT"):
+		return LAYER_BACKGROUND
+	case e.HasNamedComponent(components, "DYNAMIC_COMPONENT") || e.HasNamedComponent(components, "PICKUP_COMPONENT"):
+		return LAYER_ACTORS
+	default:
+		return LAYER_TERRAIN
+	}
 }
@@ -0,0 +1,30 @@
+package ecs
+
+import (
+	"github.com/veandco/go-sdl2/img"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// SaveScreenshot writes what the renderer has drawn so far to a PNG file.
+// With a window, call it before the frame is presented, afterwards the frame is gone.
+func SaveScreenshot(renderer *sdl.Renderer, path string) error {
+	width, height, err := renderer.GetOutputSize()
+
+	if err != nil {
+		return err
+	}
+
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, width, height, 32, uint32(sdl.PIXELFORMAT_ARGB8888))
+
+	if err != nil {
+		return err
+	}
+
+	defer surface.Free()
+
+	if err := renderer.ReadPixels(nil, uint32(sdl.PIXELFORMAT_ARGB8888), surface.Data(), int(surface.Pitch)); err != nil {
+		return err
+	}
+
+	return img.SavePNG(surface, path)
+}
@@ -0,0 +1,66 @@
+package ecs
+
+import (
+	"github.com/t-puetz/GoJumpAndRunAndShoot/input"
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type SwitchComponentData struct {
+	On bool
+}
+
+// SwitchSystem toggles the switches (levers, buttons...) the player stands in front of
+// when the interact key is pressed. What a switch does is up to the entities linked to it,
+// see DoorSystem.
+type SwitchSystem struct {
+	*CommonSystemData
+	Keyboard *input.Keyboard
+}
+
+func NewSwitchSystem(e *ECSManager, k *input.Keyboard) *SwitchSystem {
+	return &SwitchSystem{
+		CommonSystemData: NewCommonSystemData("SWITCH_COMPONENT", e),
+		Keyboard:         k,
+	}
+}
+
+func (sys *SwitchSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+	entityToComponentMapOrdered := ecsManager.EntityToComponentMap
+
+	if !sys.Keyboard.KeyJustPressed(sdl.Keycode('e')) {
+		return
+	}
+
+	playerID, hasPlayer := ecsManager.FindPlayer()
+
+	if !hasPlayer {
+		return
+	}
+
+	playerRect := ecsManager.GetEntityRect(playerID)
+
+	for el := entityToComponentMapOrdered.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !ecsManager.HasComponent(components, sys.SystemID) {
+			continue
+		}
+
+		if !playerRect.HasIntersection(ecsManager.GetEntityRect(entityID)) {
+			continue
+		}
+
+		pSCD := sys.GetComponentData(entityID).(*SwitchComponentData)
+
+		sys.UpdateComponent(delta, pSCD)
+	}
+}
+
+func (sys *SwitchSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	pSCD := essentialData[0].(*SwitchComponentData)
+
+	pSCD.On = !pSCD.On
+}
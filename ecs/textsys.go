@@ -0,0 +1,183 @@
+package ecs
+
+import (
+	"log"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// DEFAULT_FONT is used by texts that do not name a font
+const DEFAULT_FONT = "./assets/SourceCodePro-Bold.ttf"
+
+// TextAlign decides which point of a text its entity's position is
+type TextAlign uint8
+
+const (
+	ALIGN_LEFT TextAlign = iota
+	ALIGN_CENTER
+	ALIGN_RIGHT
+)
+
+// TextAlignNames maps the alignments used in the asset descriptions to the alignments
+var TextAlignNames = map[string]TextAlign{
+	"Left":   ALIGN_LEFT,
+	"Center": ALIGN_CENTER,
+	"Right":  ALIGN_RIGHT,
+}
+
+// TextStyle is how a text looks
+type TextStyle struct {
+	FontPath string
+	FontSize int
+	Color    sdl.Color
+	Align    TextAlign
+	// Lines longer than this are wrapped, in pixels. 0 never wraps.
+	WrapWidth int
+}
+
+type renderedText struct {
+	content string
+	style   TextStyle
+}
+
+// TextComponentData is a text that may change while the game runs. It is only rendered
+// again after Content or Style changed, the size of the entity is the size of the rendered text.
+type TextComponentData struct {
+	Content string
+	Style   TextStyle
+	// What the render component shows right now
+	rendered    renderedText
+	hasRendered bool
+}
+
+func (pTXCD *TextComponentData) changed() bool {
+	return !pTXCD.hasRendered || pTXCD.rendered != renderedText{content: pTXCD.Content, style: pTXCD.Style}
+}
+
+type TextSystem struct {
+	*CommonSystemData
+}
+
+func NewTextSystem(e *ECSManager) *TextSystem {
+	return &TextSystem{
+		CommonSystemData: NewCommonSystemData("TEXT_COMPONENT", e),
+	}
+}
+
+func (sys *TextSystem) Run(delta float64, statemachine *statemachine.StateMachine) {
+	ecsManager := sys.ECSManager
+
+	if !ecsManager.textsChanged() {
+		return
+	}
+
+	// The render goroutine might be drawing the old textures right now
+	ecsManager.entityMu.Lock()
+	ecsManager.RefreshTexts()
+	ecsManager.entityMu.Unlock()
+}
+
+func (sys *TextSystem) UpdateComponent(delta float64, essentialData ...interface{}) {
+	pTXCD := essentialData[0].(*TextComponentData)
+	pRCD := essentialData[1].(*RenderComponentData)
+
+	sys.ECSManager.renderText(pTXCD, pRCD)
+}
+
+func (e *ECSManager) textsChanged() bool {
+	for el := e.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		components := el.Value.([]uint16)
+
+		if !e.HasNamedComponent(components, "TEXT_COMPONENT") {
+			continue
+		}
+
+		if e.GetComponentDataByName(el.Key.(uint64), "TEXT_COMPONENT").(*TextComponentData).changed() {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshTexts renders all texts that changed since they were rendered last.
+// Nothing may be drawn meanwhile, so either hold the entity lock or call it while loading a level.
+func (e *ECSManager) RefreshTexts() {
+	for el := e.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !e.HasNamedComponent(components, "TEXT_COMPONENT") {
+			continue
+		}
+
+		pTXCD := e.GetComponentDataByName(entityID, "TEXT_COMPONENT").(*TextComponentData)
+
+		if !pTXCD.changed() {
+			continue
+		}
+
+		e.renderText(pTXCD, e.GetComponentDataByName(entityID, "RENDER_COMPONENT").(*RenderComponentData))
+	}
+}
+
+// renderText replaces the text shown by the render component with the current content
+func (e *ECSManager) renderText(pTXCD *TextComponentData, pRCD *RenderComponentData) {
+	pTXCD.rendered = renderedText{content: pTXCD.Content, style: pTXCD.Style}
+	pTXCD.hasRendered = true
+	pRCD.TextAlign = pTXCD.Style.Align
+
+	var text *sdl.Surface
+	var texture *sdl.Texture
+
+	// SDL_ttf cannot render empty texts, there is just nothing to show then
+	if pTXCD.Content != "" {
+		var err error
+
+		text, texture, err = e.rasterizeText(pTXCD.Content, pTXCD.Style)
+
+		if err != nil {
+			log.Printf("Not able to render text %q: %s\n", pTXCD.Content, err)
+		}
+	}
+
+	if pRCD.Text != nil {
+		pRCD.Text.Free()
+	}
+
+	if pRCD.Texture != nil {
+		_ = pRCD.Texture.Destroy()
+	}
+
+	pRCD.Text = text
+	pRCD.Texture = texture
+}
+
+func (e *ECSManager) rasterizeText(content string, style TextStyle) (*sdl.Surface, *sdl.Texture, error) {
+	font, err := e.Fonts.Get(style.FontPath, style.FontSize)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var text *sdl.Surface
+
+	if style.WrapWidth > 0 {
+		text, err = font.RenderUTF8BlendedWrapped(content, style.Color, style.WrapWidth)
+	} else {
+		text, err = font.RenderUTF8Blended(content, style.Color)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	texture, err := e.Textures.Renderer.CreateTextureFromSurface(text)
+
+	if err != nil {
+		text.Free()
+		return nil, nil, err
+	}
+
+	return text, texture, nil
+}
@@ -0,0 +1,105 @@
+package ecs
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/veandco/go-sdl2/img"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type textureCacheEntry struct {
+	Image      *sdl.Surface
+	Texture    *sdl.Texture
+	references int
+}
+
+// TextureCacheStats tells how well the TextureCache is doing, e.g. for debugging
+type TextureCacheStats struct {
+	// Images currently loaded and how many references to them are held
+	Entries    int
+	References int
+	// Counted since the cache was created
+	Loads uint64
+	Hits  uint64
+	Frees uint64
+}
+
+func (s TextureCacheStats) String() string {
+	return fmt.Sprintf("%d images with %d references, %d loads, %d hits, %d frees", s.Entries, s.References, s.Loads, s.Hits, s.Frees)
+}
+
+// TextureCache loads every image file once, no matter how many entities use it.
+// Every Acquire of a path has to be matched by a Release, the image and its texture
+// are freed when the last reference is released.
+type TextureCache struct {
+	Renderer *sdl.Renderer
+	entries  map[string]*textureCacheEntry
+	stats    TextureCacheStats
+}
+
+func NewTextureCache(renderer *sdl.Renderer) *TextureCache {
+	return &TextureCache{
+		Renderer: renderer,
+		entries:  make(map[string]*textureCacheEntry),
+	}
+}
+
+// Acquire returns the image at path and its texture, loading them if nobody holds them yet
+func (c *TextureCache) Acquire(path string) (*sdl.Surface, *sdl.Texture, error) {
+	if entry, ok := c.entries[path]; ok {
+		entry.references++
+		c.stats.Hits++
+		return entry.Image, entry.Texture, nil
+	}
+
+	pImage, err := img.Load(path)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pTexture, err := c.Renderer.CreateTextureFromSurface(pImage)
+
+	if err != nil {
+		pImage.Free()
+		return nil, nil, err
+	}
+
+	c.entries[path] = &textureCacheEntry{Image: pImage, Texture: pTexture, references: 1}
+	c.stats.Loads++
+
+	return pImage, pTexture, nil
+}
+
+// Release gives back one reference to the image at path
+func (c *TextureCache) Release(path string) {
+	entry, ok := c.entries[path]
+
+	if !ok {
+		log.Printf("Releasing image %s which is not in the texture cache\n", path)
+		return
+	}
+
+	entry.references--
+
+	if entry.references > 0 {
+		return
+	}
+
+	entry.Image.Free()
+	_ = entry.Texture.Destroy()
+	delete(c.entries, path)
+	c.stats.Frees++
+}
+
+func (c *TextureCache) Stats() TextureCacheStats {
+	stats := c.stats
+	stats.Entries = len(c.entries)
+
+	for _, entry := range c.entries {
+		stats.References += entry.references
+	}
+
+	return stats
+}
@@ -0,0 +1,121 @@
+package ecs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	testImage      = "../assets/Items/coin.png"
+	otherTestImage = "../assets/Items/bullet.png"
+)
+
+// newTestRenderer draws to an image in memory, so the tests run without a screen
+func newTestRenderer(t *testing.T) *sdl.Renderer {
+	t.Helper()
+
+	if os.Getenv("SDL_VIDEODRIVER") == "" {
+		t.Setenv("SDL_VIDEODRIVER", "dummy")
+	}
+
+	if err := sdl.Init(sdl.INIT_VIDEO); err != nil {
+		t.Fatalf("Failed to initialize SDL: %s", err)
+	}
+
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, 64, 64, 32, uint32(sdl.PIXELFORMAT_ARGB8888))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renderer, err := sdl.CreateSoftwareRenderer(surface)
+
+	if err != nil {
+		surface.Free()
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = renderer.Destroy()
+		surface.Free()
+		sdl.Quit()
+	})
+
+	return renderer
+}
+
+func checkTextureCacheStats(t *testing.T, cache *TextureCache, want TextureCacheStats) {
+	t.Helper()
+
+	if got := cache.Stats(); got != want {
+		t.Errorf("Stats() = %s, want %s", got, want)
+	}
+}
+
+func TestTextureCacheSharesImages(t *testing.T) {
+	cache := NewTextureCache(newTestRenderer(t))
+
+	image, texture, err := cache.Acquire(testImage)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sameImage, sameTexture, err := cache.Acquire(testImage)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sameImage != image || sameTexture != texture {
+		t.Error("Acquiring the same path twice loaded the image twice")
+	}
+
+	if _, _, err := cache.Acquire(otherTestImage); err != nil {
+		t.Fatal(err)
+	}
+
+	checkTextureCacheStats(t, cache, TextureCacheStats{Entries: 2, References: 3, Loads: 2, Hits: 1})
+
+	cache.Release(testImage)
+	checkTextureCacheStats(t, cache, TextureCacheStats{Entries: 2, References: 2, Loads: 2, Hits: 1})
+
+	cache.Release(testImage)
+	cache.Release(otherTestImage)
+	checkTextureCacheStats(t, cache, TextureCacheStats{Loads: 2, Hits: 1, Frees: 2})
+
+	// Loaded again once the last reference is gone
+	if _, _, err := cache.Acquire(testImage); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.Release(testImage)
+	checkTextureCacheStats(t, cache, TextureCacheStats{Loads: 3, Hits: 1, Frees: 3})
+}
+
+func TestTextureCacheIgnoresUnknownReleases(t *testing.T) {
+	cache := NewTextureCache(newTestRenderer(t))
+
+	if _, _, err := cache.Acquire(testImage); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.Release(otherTestImage)
+	checkTextureCacheStats(t, cache, TextureCacheStats{Entries: 1, References: 1, Loads: 1})
+
+	cache.Release(testImage)
+	cache.Release(testImage)
+	checkTextureCacheStats(t, cache, TextureCacheStats{Loads: 1, Frees: 1})
+}
+
+func TestTextureCacheMissingImage(t *testing.T) {
+	cache := NewTextureCache(newTestRenderer(t))
+
+	if _, _, err := cache.Acquire("../assets/missing.png"); err == nil {
+		t.Fatal("Acquire() of a missing image did not fail")
+	}
+
+	checkTextureCacheStats(t, cache, TextureCacheStats{})
+}
@@ -0,0 +1,233 @@
+package ecs
+
+import (
+	"log"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// NO_TILE marks an empty cell of a tilemap
+const NO_TILE int32 = -1
+
+// TILEMAP_CHUNK_SIZE is the width and height in cells of the chunks a tilemap is baked into
+const TILEMAP_CHUNK_SIZE int32 = 16
+
+// Tile is one entry of a tilemap's tileset
+type Tile struct {
+	Path    string
+	Image   *sdl.Surface
+	Texture *sdl.Texture
+	// Solid tiles collide like any other static entity
+	Solid    bool
+	Friction float64
+}
+
+type tilemapChunk struct {
+	column int32
+	row    int32
+}
+
+// TilemapComponentData is a grid of tiles, with the entity's Transform as its top left corner.
+// One tilemap replaces hundreds of single terrain entities.
+type TilemapComponentData struct {
+	TileWidth  int32
+	TileHeight int32
+	Columns    int32
+	Rows       int32
+	// Row by row, every cell is an index into Tileset or NO_TILE
+	Cells   []int32
+	Tileset []*Tile
+	// Baked the first time they are visible, nil if the renderer cannot render to textures
+	chunks map[tilemapChunk]*sdl.Texture
+}
+
+// TileAt returns the tile in the given cell, nil for empty cells and cells outside the map
+func (pTMCD *TilemapComponentData) TileAt(column, row int32) *Tile {
+	if column < 0 || row < 0 || column >= pTMCD.Columns || row >= pTMCD.Rows {
+		return nil
+	}
+
+	index := pTMCD.Cells[row*pTMCD.Columns+column]
+
+	if index == NO_TILE {
+		return nil
+	}
+
+	return pTMCD.Tileset[index]
+}
+
+// Size is the width and height of the whole map
+func (pTMCD *TilemapComponentData) Size() (int32, int32) {
+	return pTMCD.Columns * pTMCD.TileWidth, pTMCD.Rows * pTMCD.TileHeight
+}
+
+// cellRange returns the first and last column and row of the cells overlapping rect,
+// for a map with its top left corner at originX, originY. It returns false if rect misses the map.
+func (pTMCD *TilemapComponentData) cellRange(rect *sdl.Rect, originX, originY int32) (int32, int32, int32, int32, bool) {
+	width, height := pTMCD.Size()
+	mapRect := &sdl.Rect{X: originX, Y: originY, W: width, H: height}
+
+	overlap, overlaps := rect.Intersect(mapRect)
+
+	if !overlaps {
+		return 0, 0, 0, 0, false
+	}
+
+	firstColumn := (overlap.X - originX) / pTMCD.TileWidth
+	firstRow := (overlap.Y - originY) / pTMCD.TileHeight
+	lastColumn := (overlap.X + overlap.W - 1 - originX) / pTMCD.TileWidth
+	lastRow := (overlap.Y + overlap.H - 1 - originY) / pTMCD.TileHeight
+
+	return firstColumn, firstRow, lastColumn, lastRow, true
+}
+
+// tileRect is where the tile in the given cell is, tiles smaller than a cell sit in its top left corner
+func (pTMCD *TilemapComponentData) tileRect(tile *Tile, column, row, originX, originY int32) *sdl.Rect {
+	return &sdl.Rect{X: originX + column*pTMCD.TileWidth, Y: originY + row*pTMCD.TileHeight, W: tile.Image.W, H: tile.Image.H}
+}
+
+// solidTileAt reports whether rect overlaps a solid tile of the tilemap entity
+func (e *ECSManager) solidTileAt(entityID uint64, rect *sdl.Rect) bool {
+	pTMCD := e.GetComponentDataByName(entityID, "TILEMAP_COMPONENT").(*TilemapComponentData)
+	pTCD := e.GetComponentDataByName(entityID, "TRANSFORM_COMPONENT").(*TransformComponentData)
+
+	firstColumn, firstRow, lastColumn, lastRow, overlaps := pTMCD.cellRange(rect, pTCD.PosX, pTCD.PosY)
+
+	if !overlaps {
+		return false
+	}
+
+	for row := firstRow; row <= lastRow; row++ {
+		for column := firstColumn; column <= lastColumn; column++ {
+			tile := pTMCD.TileAt(column, row)
+
+			if tile == nil || !tile.Solid {
+				continue
+			}
+
+			if rect.HasIntersection(pTMCD.tileRect(tile, column, row, pTCD.PosX, pTCD.PosY)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// releaseTilemap gives back the tileset images and destroys the baked chunks
+func (e *ECSManager) releaseTilemap(pTMCD *TilemapComponentData) {
+	for _, tile := range pTMCD.Tileset {
+		e.Textures.Release(tile.Path)
+	}
+
+	for key, chunk := range pTMCD.chunks {
+		_ = chunk.Destroy()
+		delete(pTMCD.chunks, key)
+	}
+
+	pTMCD.Tileset = nil
+}
+
+// tilemapRenderer draws the visible part of tilemaps for the RenderSystem,
+// so tilemaps are drawn in order with all other entities
+type tilemapRenderer struct {
+	renderer *sdl.Renderer
+	// Set once creating a chunk texture failed, tiles are drawn one by one from then on
+	noChunks bool
+}
+
+// draw draws the part of the tilemap at dstRect that is inside the viewport
+func (t *tilemapRenderer) draw(pTMCD *TilemapComponentData, dstRect *sdl.Rect, viewport *sdl.Rect) {
+	firstColumn, firstRow, lastColumn, lastRow, visible := pTMCD.cellRange(viewport, dstRect.X, dstRect.Y)
+
+	if !visible {
+		return
+	}
+
+	if t.noChunks {
+		t.drawCells(pTMCD, firstColumn, firstRow, lastColumn, lastRow, dstRect.X, dstRect.Y)
+		return
+	}
+
+	chunkWidth := TILEMAP_CHUNK_SIZE * pTMCD.TileWidth
+	chunkHeight := TILEMAP_CHUNK_SIZE * pTMCD.TileHeight
+
+	for chunkRow := firstRow / TILEMAP_CHUNK_SIZE; chunkRow <= lastRow/TILEMAP_CHUNK_SIZE; chunkRow++ {
+		for chunkColumn := firstColumn / TILEMAP_CHUNK_SIZE; chunkColumn <= lastColumn/TILEMAP_CHUNK_SIZE; chunkColumn++ {
+			chunk, baked := t.chunk(pTMCD, tilemapChunk{column: chunkColumn, row: chunkRow})
+
+			if !baked {
+				// Draw what is left of this frame tile by tile
+				t.drawCells(pTMCD, firstColumn, firstRow, lastColumn, lastRow, dstRect.X, dstRect.Y)
+				return
+			}
+
+			chunkRect := &sdl.Rect{X: dstRect.X + chunkColumn*chunkWidth, Y: dstRect.Y + chunkRow*chunkHeight, W: chunkWidth, H: chunkHeight}
+			t.renderer.Copy(chunk, nil, chunkRect)
+		}
+	}
+}
+
+// drawCells draws the tiles of the given cells one by one
+func (t *tilemapRenderer) drawCells(pTMCD *TilemapComponentData, firstColumn, firstRow, lastColumn, lastRow, originX, originY int32) {
+	for row := firstRow; row <= lastRow; row++ {
+		for column := firstColumn; column <= lastColumn; column++ {
+			tile := pTMCD.TileAt(column, row)
+
+			if tile == nil {
+				continue
+			}
+
+			t.renderer.Copy(tile.Texture, nil, pTMCD.tileRect(tile, column, row, originX, originY))
+		}
+	}
+}
+
+// chunk returns the texture with all tiles of the chunk, baking it if it was not visible before.
+// It returns false if the renderer cannot render to textures.
+func (t *tilemapRenderer) chunk(pTMCD *TilemapComponentData, key tilemapChunk) (*sdl.Texture, bool) {
+	if chunk, ok := pTMCD.chunks[key]; ok {
+		return chunk, true
+	}
+
+	chunkWidth := TILEMAP_CHUNK_SIZE * pTMCD.TileWidth
+	chunkHeight := TILEMAP_CHUNK_SIZE * pTMCD.TileHeight
+
+	chunk, err := t.renderer.CreateTexture(sdl.PIXELFORMAT_RGBA8888, sdl.TEXTUREACCESS_TARGET, chunkWidth, chunkHeight)
+
+	if err != nil {
+		log.Printf("Not able to bake tilemap chunks, drawing tiles one by one: %s\n", err)
+		t.noChunks = true
+		return nil, false
+	}
+
+	_ = chunk.SetBlendMode(sdl.BLENDMODE_BLEND)
+
+	if err := t.renderer.SetRenderTarget(chunk); err != nil {
+		log.Printf("Not able to bake tilemap chunks, drawing tiles one by one: %s\n", err)
+		_ = chunk.Destroy()
+		t.noChunks = true
+		return nil, false
+	}
+
+	r, g, b, a, _ := t.renderer.GetDrawColor()
+	_ = t.renderer.SetDrawColor(0, 0, 0, 0)
+	_ = t.renderer.Clear()
+	_ = t.renderer.SetDrawColor(r, g, b, a)
+
+	firstColumn := key.column * TILEMAP_CHUNK_SIZE
+	firstRow := key.row * TILEMAP_CHUNK_SIZE
+
+	// Inside the chunk texture the chunk's first cell is at 0, 0
+	t.drawCells(pTMCD, firstColumn, firstRow, firstColumn+TILEMAP_CHUNK_SIZE-1, firstRow+TILEMAP_CHUNK_SIZE-1,
+		-firstColumn*pTMCD.TileWidth, -firstRow*pTMCD.TileHeight)
+
+	_ = t.renderer.SetRenderTarget(nil)
+
+	if pTMCD.chunks == nil {
+		pTMCD.chunks = make(map[tilemapChunk]*sdl.Texture)
+	}
+
+	pTMCD.chunks[key] = chunk
+
+	return chunk, true
+}
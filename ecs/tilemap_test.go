@@ -0,0 +1,129 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// newTestTilemap is 3 by 2 cells of 70 pixels:
+//
+//	G.s
+//	.GG
+//
+// with G a solid tile filling its cell and s a smaller tile that is not solid
+func newTestTilemap() *TilemapComponentData {
+	return &TilemapComponentData{
+		TileWidth:  70,
+		TileHeight: 70,
+		Columns:    3,
+		Rows:       2,
+		Cells:      []int32{0, NO_TILE, 1, NO_TILE, 0, 0},
+		Tileset: []*Tile{
+			{Path: "grass.png", Image: &sdl.Surface{W: 70, H: 70}, Solid: true},
+			{Path: "small.png", Image: &sdl.Surface{W: 35, H: 35}},
+		},
+	}
+}
+
+func TestTilemapSize(t *testing.T) {
+	if w, h := newTestTilemap().Size(); w != 210 || h != 140 {
+		t.Errorf("Size() = %dx%d, want 210x140", w, h)
+	}
+}
+
+func TestTilemapTileAt(t *testing.T) {
+	pTMCD := newTestTilemap()
+
+	tests := []struct {
+		column, row int32
+		want        *Tile
+	}{
+		{0, 0, pTMCD.Tileset[0]},
+		{1, 0, nil},
+		{2, 0, pTMCD.Tileset[1]},
+		{0, 1, nil},
+		{2, 1, pTMCD.Tileset[0]},
+		{-1, 0, nil},
+		{0, -1, nil},
+		{0, 2, nil},
+		// Would be the first cell of the next row if the column was not checked
+		{3, 0, nil},
+	}
+
+	for _, test := range tests {
+		if got := pTMCD.TileAt(test.column, test.row); got != test.want {
+			t.Errorf("TileAt(%d, %d) = %+v, want %+v", test.column, test.row, got, test.want)
+		}
+	}
+}
+
+func TestTilemapCellRange(t *testing.T) {
+	tests := []struct {
+		name             string
+		originX, originY int32
+		rect             sdl.Rect
+		first, last      [2]int32
+		overlaps         bool
+	}{
+		{"whole map", 0, 0, sdl.Rect{X: 0, Y: 0, W: 210, H: 140}, [2]int32{0, 0}, [2]int32{2, 1}, true},
+		{"inside one cell", 0, 0, sdl.Rect{X: 10, Y: 10, W: 20, H: 20}, [2]int32{0, 0}, [2]int32{0, 0}, true},
+		{"across cell borders", 0, 0, sdl.Rect{X: 60, Y: 60, W: 20, H: 20}, [2]int32{0, 0}, [2]int32{1, 1}, true},
+		{"larger than the map", 0, 0, sdl.Rect{X: -100, Y: -100, W: 1000, H: 1000}, [2]int32{0, 0}, [2]int32{2, 1}, true},
+		{"last pixel", 0, 0, sdl.Rect{X: 209, Y: 139, W: 5, H: 5}, [2]int32{2, 1}, [2]int32{2, 1}, true},
+		{"ends at the left edge", 0, 0, sdl.Rect{X: -50, Y: 0, W: 50, H: 70}, [2]int32{}, [2]int32{}, false},
+		{"starts at the right edge", 0, 0, sdl.Rect{X: 210, Y: 0, W: 10, H: 10}, [2]int32{}, [2]int32{}, false},
+		{"starts at the bottom edge", 0, 0, sdl.Rect{X: 0, Y: 140, W: 10, H: 10}, [2]int32{}, [2]int32{}, false},
+		{"negative origin", -100, -50, sdl.Rect{X: 0, Y: 0, W: 10, H: 10}, [2]int32{1, 0}, [2]int32{1, 0}, true},
+		{"negative origin, first pixel", -100, -50, sdl.Rect{X: -100, Y: -50, W: 1, H: 1}, [2]int32{0, 0}, [2]int32{0, 0}, true},
+		{"negative origin, negative rect", -100, -50, sdl.Rect{X: -31, Y: -1, W: 2, H: 2}, [2]int32{0, 0}, [2]int32{1, 0}, true},
+		{"negative origin, right of the map", -100, -50, sdl.Rect{X: 110, Y: 0, W: 5, H: 5}, [2]int32{}, [2]int32{}, false},
+		{"empty rect", 0, 0, sdl.Rect{X: 10, Y: 10}, [2]int32{}, [2]int32{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			firstColumn, firstRow, lastColumn, lastRow, overlaps := newTestTilemap().cellRange(&test.rect, test.originX, test.originY)
+
+			if overlaps != test.overlaps {
+				t.Fatalf("cellRange() overlaps = %t, want %t", overlaps, test.overlaps)
+			}
+
+			if !overlaps {
+				return
+			}
+
+			first, last := [2]int32{firstColumn, firstRow}, [2]int32{lastColumn, lastRow}
+
+			if first != test.first || last != test.last {
+				t.Errorf("cellRange() = %v to %v, want %v to %v", first, last, test.first, test.last)
+			}
+		})
+	}
+}
+
+func TestTilemapTileRect(t *testing.T) {
+	pTMCD := newTestTilemap()
+
+	tests := []struct {
+		name             string
+		column, row      int32
+		originX, originY int32
+		want             sdl.Rect
+	}{
+		{"first cell", 0, 0, 0, 0, sdl.Rect{X: 0, Y: 0, W: 70, H: 70}},
+		{"last cell", 2, 1, 0, 0, sdl.Rect{X: 140, Y: 70, W: 70, H: 70}},
+		{"negative origin", 2, 1, -100, -50, sdl.Rect{X: 40, Y: 20, W: 70, H: 70}},
+		{"small tile in the top left corner", 2, 0, -100, -50, sdl.Rect{X: 40, Y: -50, W: 35, H: 35}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tile := pTMCD.TileAt(test.column, test.row)
+
+			if got := pTMCD.tileRect(tile, test.column, test.row, test.originX, test.originY); *got != test.want {
+				t.Errorf("tileRect() = %v, want %v", *got, test.want)
+			}
+		})
+	}
+}
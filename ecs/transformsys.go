@@ -18,6 +18,7 @@ type TransformComponentData struct {
 	Vspeed      int32
 	IsJumping   bool
 	IsNotMoving bool
+	IsCrouching bool
 }
 
 type TransformSystem struct {
@@ -8,19 +8,139 @@ import (
 type animation struct {
 	SpritesheetAvailable bool   `json:"SpritesheetAvailable"`
 	Spritesheet          string `json:"Spritesheet"`
-	NumberAnimations     uint8   `json:"NumberAnimations"`
+	NumberAnimations     uint8  `json:"NumberAnimations"`
 	Image                string `json:"Image"`
 	ImageBasePath        string `json:"ImageBasePath"`
 }
 
+type movement struct {
+	GroundAcceleration float64 `json:"GroundAcceleration"`
+	GroundDeceleration float64 `json:"GroundDeceleration"`
+	AirAcceleration    float64 `json:"AirAcceleration"`
+	AirDeceleration    float64 `json:"AirDeceleration"`
+	MaxGroundSpeed     float64 `json:"MaxGroundSpeed"`
+	MaxAirSpeed        float64 `json:"MaxAirSpeed"`
+	JumpSpeed          float64 `json:"JumpSpeed"`
+	JumpReleaseSpeed   float64 `json:"JumpReleaseSpeed"`
+	CoyoteTimeMs       float64 `json:"CoyoteTimeMs"`
+	JumpBufferMs       float64 `json:"JumpBufferMs"`
+	CrouchSpeedFactor  float64 `json:"CrouchSpeedFactor"`
+}
+
+type weapon struct {
+	Projectile string  `json:"Projectile"`
+	Speed      float64 `json:"Speed"`
+	LifetimeMs float64 `json:"LifetimeMs"`
+	FireRateMs float64 `json:"FireRateMs"`
+	Damage     int32   `json:"Damage"`
+	// Weapons using ammo take it from the inventory, which starts a run with StartingAmmo
+	UsesAmmo     bool  `json:"UsesAmmo"`
+	StartingAmmo int32 `json:"StartingAmmo"`
+}
+
+type pickup struct {
+	Kind    string `json:"Kind"`
+	Value   int32  `json:"Value"`
+	KeyName string `json:"KeyName"`
+}
+
+type health struct {
+	MaxHP             int32   `json:"MaxHP"`
+	InvulnerabilityMs float64 `json:"InvulnerabilityMs"`
+	HurtMs            float64 `json:"HurtMs"`
+	BlinkIntervalMs   float64 `json:"BlinkIntervalMs"`
+	KnockbackX        int32   `json:"KnockbackX"`
+	KnockbackY        int32   `json:"KnockbackY"`
+}
+
+type breakable struct {
+	HP        int32  `json:"HP"`
+	LootTable string `json:"LootTable"`
+}
+
+type color struct {
+	R uint8 `json:"R"`
+	G uint8 `json:"G"`
+	B uint8 `json:"B"`
+	A uint8 `json:"A"`
+}
+
+// displayConfig is the resolution the game is drawn in and how it is shown, see display.json
+type displayConfig struct {
+	// Positions in the level descriptions are in this resolution, it is scaled to the window
+	LogicalWidth  int32 `json:"LogicalWidth"`
+	LogicalHeight int32 `json:"LogicalHeight"`
+	WindowWidth   int32 `json:"WindowWidth"`
+	WindowHeight  int32 `json:"WindowHeight"`
+	Resizable     bool  `json:"Resizable"`
+	Fullscreen    bool  `json:"Fullscreen"`
+}
+
+type camera struct {
+	// Entity ID or name of the entity to follow, the player if empty
+	Target         string  `json:"Target"`
+	DeadZoneWidth  int32   `json:"DeadZoneWidth"`
+	DeadZoneHeight int32   `json:"DeadZoneHeight"`
+	LookAhead      float64 `json:"LookAhead"`
+	Smoothing      float64 `json:"Smoothing"`
+}
+
 type AssetJSONConfig struct {
 	AnimatedByDefault        bool                   `json:"AnimatedByDefault"`
 	ImagesBasePath           string                 `json:"ImagesBasePath"`
 	Image                    string                 `json:"Image"`
-	DefaultAnimationDuration uint8                   `json:"DefaultAnimationDuration"`
+	DefaultAnimationDuration uint8                  `json:"DefaultAnimationDuration"`
 	Animations               *map[string]*animation `json:"Animations"`
 	FontSize                 uint8                  `json:"FontSize"`
 	Text                     string                 `json:"Text"`
+	Movement                 *movement              `json:"Movement"`
+	Friction                 *float64               `json:"Friction"`
+	Weapon                   *weapon                `json:"Weapon"`
+	Health                   *health                `json:"Health"`
+	ContactDamage            int32                  `json:"ContactDamage"`
+	Pickup                   *pickup                `json:"Pickup"`
+	Breakable                *breakable             `json:"Breakable"`
+	Camera                   *camera                `json:"Camera"`
+
+	// Path of the text's font, ecs.DEFAULT_FONT if empty
+	Font string `json:"Font"`
+	// Red if not given
+	Color *color `json:"Color"`
+	// One of "Left", "Center" and "Right", see ecs.TextAlignNames
+	Align string `json:"Align"`
+	// Width in pixels the text is wrapped at, 0 never wraps
+	WrapWidth int `json:"WrapWidth"`
+
+	// Components of entities spawned from this asset at runtime
+	Components []uint16 `json:"Components"`
+}
+
+func LoadDisplayConfig(Game *Game) {
+	config := &displayConfig{}
+
+	data, readInErr := ioutil.ReadFile("./game/display.json")
+
+	if readInErr != nil {
+		panic(readInErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, config)
+
+	if unmarshalErr != nil {
+		panic(unmarshalErr)
+	}
+
+	if config.LogicalWidth <= 0 || config.LogicalHeight <= 0 {
+		panic("display.json needs a LogicalWidth and LogicalHeight")
+	}
+
+	// The window starts as big as the logical resolution unless told otherwise
+	if config.WindowWidth <= 0 || config.WindowHeight <= 0 {
+		config.WindowWidth = config.LogicalWidth
+		config.WindowHeight = config.LogicalHeight
+	}
+
+	Game.DisplayConfig = config
 }
 
 func LoadAssetDescriptions(Game *Game) {
@@ -44,4 +164,3 @@ func LoadAssetDescriptions(Game *Game) {
 
 	Game.AssetDescriptions = &assetDescriptions
 }
-
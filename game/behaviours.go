@@ -0,0 +1,28 @@
+package game
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/behaviourtree"
+)
+
+// LoadBehaviourDescriptions reads the behaviour trees enemies can refer to by name
+// via "BehaviourTree" in the level description
+func LoadBehaviourDescriptions(Game *Game) {
+	behaviourDescriptions := make(map[string]*behaviourtree.NodeConfig)
+
+	data, readInErr := ioutil.ReadFile("./game/behaviours.json")
+
+	if readInErr != nil {
+		panic(readInErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, &behaviourDescriptions)
+
+	if unmarshalErr != nil {
+		panic(unmarshalErr)
+	}
+
+	Game.BehaviourDescriptions = &behaviourDescriptions
+}
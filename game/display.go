@@ -0,0 +1,148 @@
+package game
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Display is what the game is drawn to, a window or an image in memory.
+// Everything is drawn in the logical resolution and scaled to the display.
+type Display interface {
+	Renderer() *sdl.Renderer
+	// LogicalSize is the resolution the game is drawn in, whatever the size of the display
+	LogicalSize() (int32, int32)
+	Fullscreen() bool
+	SetFullscreen(fullscreen bool) error
+	// Destroy frees the renderer and everything else of the display
+	Destroy()
+}
+
+// setLogicalSize lets the renderer scale the logical resolution to its output,
+// with black bars where the aspect ratios differ
+func setLogicalSize(renderer *sdl.Renderer, config *displayConfig) error {
+	return renderer.SetLogicalSize(config.LogicalWidth, config.LogicalHeight)
+}
+
+// windowDisplay draws to a window on the screen with a hardware accelerated renderer
+type windowDisplay struct {
+	window   *sdl.Window
+	renderer *sdl.Renderer
+	config   *displayConfig
+}
+
+func newWindowDisplay(title string, config *displayConfig) (*windowDisplay, error) {
+	var flags uint32 = sdl.WINDOW_SHOWN
+
+	if config.Resizable {
+		flags |= sdl.WINDOW_RESIZABLE
+	}
+
+	if config.Fullscreen {
+		flags |= sdl.WINDOW_FULLSCREEN_DESKTOP
+	}
+
+	window, err := sdl.CreateWindow(title, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED, config.WindowWidth, config.WindowHeight, flags)
+
+	if err != nil {
+		return nil, err
+	}
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+
+	if err == nil {
+		err = setLogicalSize(renderer, config)
+	}
+
+	if err != nil {
+		if renderer != nil {
+			_ = renderer.Destroy()
+		}
+		_ = window.Destroy()
+		return nil, err
+	}
+
+	return &windowDisplay{window: window, renderer: renderer, config: config}, nil
+}
+
+func (d *windowDisplay) Renderer() *sdl.Renderer {
+	return d.renderer
+}
+
+func (d *windowDisplay) LogicalSize() (int32, int32) {
+	return d.config.LogicalWidth, d.config.LogicalHeight
+}
+
+func (d *windowDisplay) Fullscreen() bool {
+	return d.window.GetFlags()&sdl.WINDOW_FULLSCREEN_DESKTOP != 0
+}
+
+// SetFullscreen switches between the window and fullscreen at the desktop's resolution,
+// the logical size keeps the game looking the same
+func (d *windowDisplay) SetFullscreen(fullscreen bool) error {
+	var flags uint32
+
+	if fullscreen {
+		flags = sdl.WINDOW_FULLSCREEN_DESKTOP
+	}
+
+	return d.window.SetFullscreen(flags)
+}
+
+func (d *windowDisplay) Destroy() {
+	_ = d.renderer.Destroy()
+	_ = d.window.Destroy()
+}
+
+// offscreenDisplay draws to an image in memory with the software renderer,
+// so the game runs without a screen, e.g. on a CI machine
+type offscreenDisplay struct {
+	surface  *sdl.Surface
+	renderer *sdl.Renderer
+	config   *displayConfig
+}
+
+// newOffscreenDisplay creates an image of the logical size, so nothing is scaled
+func newOffscreenDisplay(config *displayConfig) (*offscreenDisplay, error) {
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, config.LogicalWidth, config.LogicalHeight, 32, uint32(sdl.PIXELFORMAT_ARGB8888))
+
+	if err != nil {
+		return nil, err
+	}
+
+	renderer, err := sdl.CreateSoftwareRenderer(surface)
+
+	if err == nil {
+		err = setLogicalSize(renderer, config)
+	}
+
+	if err != nil {
+		if renderer != nil {
+			_ = renderer.Destroy()
+		}
+		surface.Free()
+		return nil, err
+	}
+
+	return &offscreenDisplay{surface: surface, renderer: renderer, config: config}, nil
+}
+
+func (d *offscreenDisplay) Renderer() *sdl.Renderer {
+	return d.renderer
+}
+
+func (d *offscreenDisplay) LogicalSize() (int32, int32) {
+	return d.config.LogicalWidth, d.config.LogicalHeight
+}
+
+// Fullscreen is always false, there is no screen
+func (d *offscreenDisplay) Fullscreen() bool {
+	return false
+}
+
+func (d *offscreenDisplay) SetFullscreen(fullscreen bool) error {
+	return nil
+}
+
+func (d *offscreenDisplay) Destroy() {
+	_ = d.renderer.Destroy()
+	d.surface.Free()
+}
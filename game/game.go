@@ -1,97 +1,277 @@
 package game
 
 import (
+	"fmt"
+	"github.com/t-puetz/GoJumpAndRunAndShoot/behaviourtree"
 	"github.com/t-puetz/GoJumpAndRunAndShoot/ecs"
 	"github.com/t-puetz/GoJumpAndRunAndShoot/input"
 	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
 	"log"
+	"math/rand"
 	"os"
 	"time"
 )
 
 type Game struct {
-	Window            *sdl.Window
-	Surface           *sdl.Surface
+	// Set before InitializeSDL to draw to an image in memory instead of a window
+	Headless          bool
+	Display           Display
+	DisplayConfig     *displayConfig
 	Renderer          *sdl.Renderer
 	Keyboard          *input.Keyboard
 	ECSManager        *ecs.ECSManager
 	AssetDescriptions *map[string]*AssetJSONConfig
 	LvlDescription    *LevelJSONConfig
 	StateMachine      *statemachine.StateMachine
+	// Behaviour trees by name, see behaviours.json
+	BehaviourDescriptions *map[string]*behaviourtree.NodeConfig
+	// Weighted drops of breakable entities by name, see loottables.json
+	LootTables    *map[string][]*lootDrop
+	LevelManifest *LevelManifestJSONConfig
+	// Index of the level being played in the LevelManifest
+	CurrentLevel int
 }
 
 func (g *Game) PrepareBasicGameData() {
 	g.ECSManager = ecs.NewECSManager()
-
-	g.ECSManager.Systems[0] = ecs.NewActiveControlSystem(g.ECSManager, g.Keyboard)
-	g.ECSManager.Systems[1] = ecs.NewGravitySystem(g.ECSManager)
-	g.ECSManager.Systems[2] = ecs.NewTransformSystem(g.ECSManager)
-	g.ECSManager.Systems[3] = ecs.NewCollideSystem(g.ECSManager)
-	g.ECSManager.Systems[4] = ecs.NewAnimateSystem(g.ECSManager)
-	g.ECSManager.Systems[5] = ecs.NewSideScrollSystem(g.ECSManager)
-	g.ECSManager.Systems[6] = ecs.NewRenderSystem(g.ECSManager, g.Renderer)
+	g.ECSManager.Textures = ecs.NewTextureCache(g.Renderer)
+	g.ECSManager.Fonts = ecs.NewFontCache()
+
+	// Loot drops are random
+	rand.Seed(time.Now().UnixNano())
+
+	// The order matters: RunSystems runs them one after another, the RenderSystem last
+	g.ECSManager.Systems = append(g.ECSManager.Systems,
+		ecs.NewActiveControlSystem(g.ECSManager, g.Keyboard),
+		ecs.NewPassiveControlSystem(g.ECSManager),
+		ecs.NewBehaviourSystem(g.ECSManager),
+		ecs.NewGravitySystem(g.ECSManager),
+		ecs.NewTransformSystem(g.ECSManager),
+		ecs.NewCollideSystem(g.ECSManager),
+		ecs.NewProjectileSystem(g.ECSManager),
+		ecs.NewPickupSystem(g.ECSManager),
+		ecs.NewCheckpointSystem(g.ECSManager),
+		ecs.NewGoalSystem(g.ECSManager),
+		ecs.NewSwitchSystem(g.ECSManager, g.Keyboard),
+		ecs.NewDoorSystem(g.ECSManager),
+		ecs.NewBreakableSystem(g.ECSManager),
+		ecs.NewHealthSystem(g.ECSManager),
+		ecs.NewAnimateSystem(g.ECSManager),
+		ecs.NewCameraSystem(g.ECSManager),
+		ecs.NewInventoryDisplaySystem(g.ECSManager),
+		ecs.NewHudSystem(g.ECSManager),
+		ecs.NewTextSystem(g.ECSManager),
+		ecs.NewRenderSystem(g.ECSManager, g.Renderer),
+	)
 
 	g.StateMachine = statemachine.NewStateMachine()
 }
 
 func (g *Game) InitializeSDL() {
+	// Without a screen SDL still needs a video driver for events and the keyboard
+	if g.Headless && os.Getenv("SDL_VIDEODRIVER") == "" {
+		_ = os.Setenv("SDL_VIDEODRIVER", "dummy")
+	}
+
 	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
 		panic(err)
 	}
 
-	window, winCreationErr := sdl.CreateWindow("Alone outside of Space :'(", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
-		1368, 720, sdl.WINDOW_SHOWN)
-	if winCreationErr != nil {
-		panic(winCreationErr)
-	}
+	LoadDisplayConfig(g)
 
-	renderer, rendererCreationErr := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	var display Display
+	var err error
 
-	if rendererCreationErr != nil {
-		log.Fatalf("Failed to create renderer: %s\n", rendererCreationErr)
+	if g.Headless {
+		display, err = newOffscreenDisplay(g.DisplayConfig)
+	} else {
+		display, err = newWindowDisplay("Alone outside of Space :'(", g.DisplayConfig)
 	}
 
-	surface, err := window.GetSurface()
 	if err != nil {
-		panic(err)
+		log.Fatalf("Failed to create display: %s\n", err)
 	}
 
-	g.Window = window
-	g.Surface = surface
-	g.Renderer = renderer
+	g.Display = display
+	g.Renderer = display.Renderer()
 
 	// Init ttf system for rendering text
 	ttf.Init()
 
 }
 
+// LoadFirstLevel starts a new run with an empty inventory
 func (g *Game) LoadFirstLevel() {
 	LoadAssetDescriptions(g)
-	LoadLvlConfig(g, "./game/lvlone.json")
+	LoadBehaviourDescriptions(g)
+	LoadLootTables(g)
+	LoadLevelManifest(g)
+
+	g.CurrentLevel = 0
+	g.ECSManager.Inventory = ecs.NewInventory()
+	g.loadLevel(g.LevelManifest.Levels[0].Path)
+	g.ECSManager.Inventory.Ammo = startingAmmo(g)
+}
+
+// LoadNextLevel continues the run with the next level of the manifest.
+// It returns false if there is no next level or it is still locked.
+func (g *Game) LoadNextLevel() bool {
+	nextLevel := g.CurrentLevel + 1
+
+	if nextLevel >= len(g.LevelManifest.Levels) {
+		return false
+	}
+
+	if !g.LevelManifest.Levels[nextLevel].IsUnlocked(g.ECSManager.Inventory) {
+		log.Printf("Level %s is still locked\n", g.LevelManifest.Levels[nextLevel].Name)
+		return false
+	}
+
+	g.logLevelSummary()
+
+	g.CurrentLevel = nextLevel
+	g.loadLevel(g.LevelManifest.Levels[nextLevel].Path)
+
+	return true
+}
+
+// loadLevel replaces all entities of the previous level (or screen) with the ones of the given level
+func (g *Game) loadLevel(path string) {
+	LoadLvlConfig(g, path)
+
+	g.ECSManager.Lock()
+	defer g.ECSManager.Unlock()
+
+	g.ECSManager.ClearEntities()
 	InitializeLevel(g)
+
+	log.Printf("Texture cache after loading %s: %s\n", path, g.ECSManager.Textures.Stats())
+	log.Printf("Live resources after loading %s: %+v\n", path, g.ECSManager.LiveResources())
+}
+
+func (g *Game) logLevelSummary() {
+	inventory := g.ECSManager.Inventory
+
+	log.Printf("Level %s completed. Score: %d, coins: %d, ammo: %d, keys: %d\n",
+		g.LevelManifest.Levels[g.CurrentLevel].Name, inventory.Score, inventory.Coins, inventory.Ammo, len(inventory.Keys))
+}
+
+// startingAmmo is the ammo the player's weapon comes with
+func startingAmmo(g *Game) int32 {
+	playerID, hasPlayer := g.ECSManager.FindPlayer()
+
+	if !hasPlayer {
+		return 0
+	}
+
+	playerAsset := (*g.AssetDescriptions)[g.LvlDescription.GetEntityDescription(playerID).Reference]
+
+	if playerAsset.Weapon == nil {
+		return 0
+	}
+
+	return playerAsset.Weapon.StartingAmmo
+}
+
+// Inventory is what the player collected so far in this run, e.g. for the end-of-level summary
+func (g *Game) Inventory() *ecs.Inventory {
+	return g.ECSManager.Inventory
 }
 
 func (g *Game) LoadWelcomeScreen() {
 	LoadAssetDescriptions(g)
-	LoadLvlConfig(g, "./game/welcomescreen.json")
-	InitializeLevel(g)
+	g.loadLevel("./game/welcomescreen.json")
 }
 
 func (g *Game) RunSystems(delta float64) {
-	for i, system := range g.ECSManager.Systems {
-		if i == 5 {
-			continue
-		}
+	g.runSystems(delta, true)
+}
 
-		if i != 6 {
+// runSystems runs one tick. Unless asynchronous the frame is drawn and presented before it returns.
+func (g *Game) runSystems(delta float64, asynchronous bool) {
+	for _, system := range g.ECSManager.Systems {
+		switch system.(type) {
+		case *ecs.RenderSystem:
+			g.handleEvents()
+			// Entities marked by the other systems are gone before the frame is drawn
+			g.ECSManager.RemoveMarkedEntities()
+
+			if asynchronous {
+				go system.Run(delta, g.StateMachine)
+			} else {
+				system.Run(delta, g.StateMachine)
+			}
+		default:
 			system.Run(delta, g.StateMachine)
-		} else {
-			go system.Run(delta, g.StateMachine)
 		}
+	}
+}
+
+// RunFrames plays the given number of ticks as fast as possible, each of them one tick long,
+// and saves the last frame to screenshotPath unless it is empty. Meant for running without a screen.
+func (g *Game) RunFrames(frames int, screenshotPath string) {
+	for frame := 0; frame < frames && g.StateMachine.CurrentState == statemachine.GAME; frame++ {
+		if frame == frames-1 && screenshotPath != "" {
+			g.Screenshot(screenshotPath)
+		}
+
+		g.Keyboard.ResetChangedStates()
+		g.runBasicQuitKeyboardEventLoop()
+		g.runSystems(1.0, false)
+	}
+}
+
+// Screenshot saves the next frame to a PNG file at path
+func (g *Game) Screenshot(path string) {
+	g.getRenderSystem().RequestScreenshot(path)
+}
+
+func (g *Game) handleEvents() {
+	for _, event := range g.ECSManager.PollEvents() {
+		switch event.Type {
+		case ecs.ENTITY_DIED:
+			components, _ := g.ECSManager.EntityToComponentMap.Get(event.EntityID)
+
+			if g.ECSManager.HasNamedComponent(components.([]uint16), "ACTIVE_CONTROL_COMPONENT") {
+				g.ECSManager.Inventory.Lives--
+				log.Printf("Player %d died, %d lives left\n", event.EntityID, g.ECSManager.Inventory.Lives)
+
+				if g.ECSManager.Inventory.Lives <= 0 {
+					log.Printf("Game over. Score: %d\n", g.ECSManager.Inventory.Score)
+					g.StateMachine.DoTransition(statemachine.GAME, statemachine.GAME_OVER)
+					return
+				}
+
+				g.ECSManager.RespawnPlayer(event.EntityID)
+				continue
+			}
+
+			g.ECSManager.MarkEntityForRemoval(event.EntityID)
+		case ecs.LEVEL_COMPLETED:
+			if g.CurrentLevel+1 == len(g.LevelManifest.Levels) {
+				g.logLevelSummary()
+				log.Println("All levels completed")
+				g.StateMachine.DoTransition(statemachine.GAME, statemachine.WELCOME_SCREEN)
+				return
+			}
+
+			// The remaining events belong to entities of the level that is gone now
+			if g.LoadNextLevel() {
+				return
+			}
+		}
+	}
+}
 
+func (g *Game) getRenderSystem() *ecs.RenderSystem {
+	for _, system := range g.ECSManager.Systems {
+		if renderSystem, ok := system.(*ecs.RenderSystem); ok {
+			return renderSystem
+		}
 	}
+	return nil
 }
 
 func (g *Game) RunWelcomeScreen() {
@@ -101,41 +281,146 @@ func (g *Game) RunWelcomeScreen() {
 			break
 		}
 
+		g.exitIfRequested()
+
+		g.Keyboard.ResetChangedStates()
 		g.runBasicQuitKeyboardEventLoop()
 
 		g.ECSManager.Systems[0].Run(1.0, g.StateMachine)
-		g.ECSManager.Systems[6].Run(1.0, g.StateMachine)
+		g.getRenderSystem().Run(1.0, g.StateMachine)
 
 		sdl.Delay(30)
 	}
 	g.StateMachine.DoTransition(statemachine.WELCOME_SCREEN, statemachine.GAME)
 }
 
+func (g *Game) LoadGameOverScreen() {
+	g.loadLevel("./game/gameover.json")
+}
+
+// RunGameOverScreen waits for the player to either retry or go back to the menu
+func (g *Game) RunGameOverScreen() {
+	for {
+		// Is decided in ActiveControlSystem by pressing R or M:
+		if g.StateMachine.CurrentState != statemachine.GAME_OVER {
+			break
+		}
+
+		g.exitIfRequested()
+
+		g.Keyboard.ResetChangedStates()
+		g.runBasicQuitKeyboardEventLoop()
+
+		g.ECSManager.Systems[0].Run(1.0, g.StateMachine)
+		g.getRenderSystem().Run(1.0, g.StateMachine)
+
+		sdl.Delay(30)
+	}
+}
+
+// renderGamePausedText draws the frozen level with the pause text in the middle of the screen
 func (g *Game) renderGamePausedText() {
+	renderSystem := g.getRenderSystem()
+	renderSystem.SetOverlay(g.drawGamePausedText)
+	renderSystem.Run(0, g.StateMachine)
+	renderSystem.SetOverlay(nil)
+}
+
+func (g *Game) drawGamePausedText(renderer *sdl.Renderer, viewWidth, viewHeight int32) {
 	var font *ttf.Font
 	var text *sdl.Surface
 
-	font, _ = ttf.OpenFont("./assets/SourceCodePro-Bold.ttf", 32)
-	text, _ = font.RenderUTF8Blended("GAME PAUSED", sdl.Color{R: 255, G: 0, B: 0, A: 255})
-	_ = text.Blit(nil, g.Surface, &sdl.Rect{X: 684 - (text.W / 2), Y: 364 - (text.H / 2), W: 0, H: 0})
-	_ = g.Window.UpdateSurface()
+	font, err := g.ECSManager.Fonts.Get("./assets/SourceCodePro-Bold.ttf", 32)
+
+	if err != nil {
+		return
+	}
+
+	text, err = font.RenderUTF8Blended("GAME PAUSED", sdl.Color{R: 255, G: 0, B: 0, A: 255})
+
+	if err != nil {
+		return
+	}
+
+	defer text.Free()
+
+	texture, err := renderer.CreateTextureFromSurface(text)
+
+	if err != nil {
+		return
+	}
+
+	defer texture.Destroy()
+
+	_ = renderer.Copy(texture, nil, &sdl.Rect{X: viewWidth/2 - text.W/2, Y: viewHeight/2 - text.H/2, W: text.W, H: text.H})
+}
+
+// toggleFullscreen switches between window and fullscreen, bound to F11
+func (g *Game) toggleFullscreen() {
+	if err := g.Display.SetFullscreen(!g.Display.Fullscreen()); err != nil {
+		log.Printf("Not able to toggle fullscreen: %s\n", err)
+	}
+}
+
+// exitIfRequested ends the program once the player chose to exit
+func (g *Game) exitIfRequested() {
+	if g.StateMachine.CurrentState != statemachine.EXIT {
+		return
+	}
+
+	g.Shutdown()
+	os.Exit(0)
+}
+
+// Shutdown frees everything the game holds of SDL and quits SDL.
+// The game must not be used afterwards.
+func (g *Game) Shutdown() {
+	renderSystem := g.getRenderSystem()
+
+	// Neither draw nor present anything ever again
+	g.ECSManager.Lock()
+	renderSystem.Lock()
+
+	g.ECSManager.ClearEntities()
+	g.ECSManager.Fonts.Close()
+
+	log.Printf("Live resources at shutdown: %+v\n", g.ECSManager.LiveResources())
+
+	g.Display.Destroy()
+
+	ttf.Quit()
+	sdl.Quit()
 }
 
 func (g *Game) runBasicQuitKeyboardEventLoop() {
 	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 		switch t := event.(type) {
 		case *sdl.QuitEvent:
-			_ = g.Window.Destroy()
-			sdl.Quit()
+			g.Shutdown()
 			os.Exit(0)
 		case *sdl.KeyboardEvent:
+			if t.Keysym.Sym == sdl.K_F11 && t.State == sdl.PRESSED && t.Repeat == 0 {
+				g.toggleFullscreen()
+			}
+
+			if t.Keysym.Sym == sdl.K_F3 && t.State == sdl.PRESSED && t.Repeat == 0 {
+				g.ECSManager.ToggleDebugDraw()
+			}
+
 			g.Keyboard.OnEvent(t)
+		case *sdl.WindowEvent:
+			// The renderer scales the logical size to the new size by itself
+			if t.Event == sdl.WINDOWEVENT_SIZE_CHANGED {
+				log.Printf("Window resized to %dx%d\n", t.Data1, t.Data2)
+			}
 		}
 	}
 }
 
 func (g *Game) decideGameOrPauseState(delta float64) {
 	switch g.StateMachine.CurrentState {
+	case statemachine.EXIT:
+		g.exitIfRequested()
 	case statemachine.PAUSE:
 		log.Println("Game Paused")
 		if g.Keyboard.KeyHeldDown(sdl.Keycode(27)) || g.Keyboard.KeyHeldDown(sdl.Keycode(1073741896)) {
@@ -144,7 +429,23 @@ func (g *Game) decideGameOrPauseState(delta float64) {
 		}
 
 		g.renderGamePausedText()
+	case statemachine.GAME_OVER:
+		g.LoadGameOverScreen()
+		g.RunGameOverScreen()
+
+		// Going back to the menu is handled by the WELCOME_SCREEN case next frame
+		if g.StateMachine.CurrentState == statemachine.GAME {
+			g.LoadFirstLevel()
+		}
+	case statemachine.WELCOME_SCREEN:
+		g.LoadWelcomeScreen()
+		g.RunWelcomeScreen()
+		g.LoadFirstLevel()
 	case statemachine.GAME:
+		if g.Keyboard.KeyJustPressed(sdl.K_F12) {
+			g.Screenshot(fmt.Sprintf("screenshot-%s.png", time.Now().Format("20060102-150405")))
+		}
+
 		if g.Keyboard.KeyHeldDown(sdl.Keycode(1073741896)) {
 			g.StateMachine.DoTransition(statemachine.GAME, statemachine.PAUSE)
 			time.Sleep(time.Millisecond * 100)
@@ -158,7 +459,7 @@ func (g *Game) Run() {
 	var elapsedTime time.Duration
 
 	lastTime := time.Now()
-	timePerFrame := 1000.0 / 70.0
+	timePerFrame := ecs.MillisecondsPerTick
 
 	for {
 		now = time.Now()
@@ -0,0 +1,91 @@
+package game
+
+import (
+	"os"
+	"testing"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/ecs"
+	"github.com/t-puetz/GoJumpAndRunAndShoot/input"
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
+)
+
+// The game loads its assets relative to the repository
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func newTestGame(t *testing.T) *Game {
+	t.Helper()
+
+	g := &Game{Headless: true}
+	g.Keyboard = input.NewKeyboard()
+	g.InitializeSDL()
+	g.PrepareBasicGameData()
+
+	t.Cleanup(g.Shutdown)
+
+	return g
+}
+
+func clearEntities(g *Game) {
+	g.ECSManager.Lock()
+	g.ECSManager.ClearEntities()
+	g.ECSManager.Unlock()
+}
+
+func TestClearEntitiesFreesLevelResources(t *testing.T) {
+	g := newTestGame(t)
+
+	g.StateMachine.DoTransition(statemachine.WELCOME_SCREEN, statemachine.GAME)
+	g.LoadFirstLevel()
+	// Tilemap chunks are baked when they are drawn first
+	g.RunFrames(1, "")
+
+	loaded := g.ECSManager.LiveResources()
+
+	if loaded.Images == 0 || loaded.Texts == 0 || loaded.Fonts == 0 || loaded.Chunks == 0 {
+		t.Fatalf("LiveResources() after loading = %+v, want some of everything", loaded)
+	}
+
+	clearEntities(g)
+
+	if got, want := g.ECSManager.LiveResources(), (ecs.ResourceStats{Fonts: loaded.Fonts}); got != want {
+		t.Errorf("LiveResources() after ClearEntities = %+v, want %+v", got, want)
+	}
+
+	if references := g.ECSManager.Textures.Stats().References; references != 0 {
+		t.Errorf("%d texture cache references left after ClearEntities", references)
+	}
+
+	g.ECSManager.Fonts.Close()
+
+	if got := g.ECSManager.LiveResources(); got != (ecs.ResourceStats{}) {
+		t.Errorf("LiveResources() after closing the fonts = %+v, want nothing", got)
+	}
+}
+
+func TestLoadingLevelsDoesNotLeak(t *testing.T) {
+	g := newTestGame(t)
+
+	g.LoadWelcomeScreen()
+	g.LoadFirstLevel()
+	g.LoadGameOverScreen()
+	g.LoadFirstLevel()
+
+	for _, level := range g.LevelManifest.Levels[1:] {
+		g.loadLevel(level.Path)
+	}
+
+	g.LoadWelcomeScreen()
+	clearEntities(g)
+
+	stats := g.ECSManager.Textures.Stats()
+
+	if stats.Entries != 0 || stats.References != 0 || stats.Loads != stats.Frees {
+		t.Errorf("Texture cache after loading levels and clearing them: %s, want everything freed", stats)
+	}
+}
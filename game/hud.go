@@ -0,0 +1,146 @@
+package game
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/ecs"
+)
+
+// hud binds the text of an entity to values of the running game
+type hud struct {
+	// fmt format of the text with one verb per value, e.g. "Score: %d"
+	Format string `json:"Format"`
+	// See ecs.HudValueNames
+	Values []string `json:"Values"`
+	// Overrides the text asset's alignment, see ecs.TextAlignNames
+	Align string `json:"Align"`
+}
+
+type hudElement struct {
+	// Text asset that decides font, size and colour
+	Reference string `json:"Reference"`
+	// A corner, edge or the center of the screen, see hudAnchors
+	Anchor  string   `json:"Anchor"`
+	OffsetX int32    `json:"OffsetX"`
+	OffsetY int32    `json:"OffsetY"`
+	Format  string   `json:"Format"`
+	Values  []string `json:"Values"`
+}
+
+type hudJSONConfig struct {
+	Elements []*hudElement `json:"Elements"`
+}
+
+type hudAnchor struct {
+	X float64
+	Y float64
+	// Texts at the right edge grow to the left, the ones in the middle to both sides
+	Align string
+}
+
+// hudAnchors maps the anchors of hud.json to points of the logical viewport.
+// Texts are placed with their top edge at the anchor, use a negative OffsetY at the bottom.
+var hudAnchors = map[string]hudAnchor{
+	"TopLeft":     {X: 0.0, Y: 0.0, Align: "Left"},
+	"Top":         {X: 0.5, Y: 0.0, Align: "Center"},
+	"TopRight":    {X: 1.0, Y: 0.0, Align: "Right"},
+	"Left":        {X: 0.0, Y: 0.5, Align: "Left"},
+	"Center":      {X: 0.5, Y: 0.5, Align: "Center"},
+	"Right":       {X: 1.0, Y: 0.5, Align: "Right"},
+	"BottomLeft":  {X: 0.0, Y: 1.0, Align: "Left"},
+	"Bottom":      {X: 0.5, Y: 1.0, Align: "Center"},
+	"BottomRight": {X: 1.0, Y: 1.0, Align: "Right"},
+}
+
+// addHudEntities adds an entity description for every element of the HUD description at path
+// behind the level's entities, so the HUD is loaded like any other entity
+func addHudEntities(game *Game, lvl *LevelJSONConfig, path string) {
+	config := &hudJSONConfig{}
+
+	data, readInErr := ioutil.ReadFile(path)
+
+	if readInErr != nil {
+		panic(readInErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, config)
+
+	if unmarshalErr != nil {
+		panic(unmarshalErr)
+	}
+
+	var nextEntityID uint64
+
+	if lastEntity := lvl.EntitiesDescriptionsOrdered.Back(); lastEntity != nil {
+		nextEntityID = lastEntity.Key.(uint64) + 1
+	}
+
+	components := []uint16{
+		game.ECSManager.ComponentIDStorage["TRANSFORM_COMPONENT"],
+		game.ECSManager.ComponentIDStorage["RENDER_COMPONENT"],
+		game.ECSManager.ComponentIDStorage["TEXT_COMPONENT"],
+		game.ECSManager.ComponentIDStorage["HUD_COMPONENT"],
+	}
+
+	for _, element := range config.Elements {
+		elementAnchor, ok := hudAnchors[element.Anchor]
+
+		if !ok {
+			log.Fatalf("HUD element %q in %s has the unknown anchor %s\n", element.Format, path, element.Anchor)
+		}
+
+		lvl.EntitiesDescriptionsOrdered.Set(nextEntityID, &EntityJSONConfig{
+			Reference:   element.Reference,
+			Components:  components,
+			InitialPosX: element.OffsetX,
+			InitialPosY: element.OffsetY,
+			Anchor:      &anchor{X: elementAnchor.X, Y: elementAnchor.Y},
+			Layer:       "UI",
+			Hud:         &hud{Format: element.Format, Values: element.Values, Align: elementAnchor.Align},
+		})
+
+		nextEntityID++
+	}
+}
+
+func HudSystemSetInitialVals(g *Game) {
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "HUD_COMPONENT") {
+			continue
+		}
+
+		entityHud := g.LvlDescription.GetEntityDescription(entityID).Hud
+
+		if entityHud == nil || !g.ECSManager.HasNamedComponent(components, "TEXT_COMPONENT") {
+			log.Fatalf("Entity number %d has a HudComponent but no Hud description or TextComponent\n", entityID)
+		}
+
+		for _, value := range entityHud.Values {
+			if !ecs.HudValueNames[value] {
+				log.Fatalf("HUD entity %d shows the unknown value %s\n", entityID, value)
+			}
+		}
+
+		pHUDCD := g.ECSManager.GetComponentDataByName(entityID, "HUD_COMPONENT").(*ecs.HudComponentData)
+		pHUDCD.Format = entityHud.Format
+		pHUDCD.Values = entityHud.Values
+
+		if entityHud.Align != "" {
+			align, ok := ecs.TextAlignNames[entityHud.Align]
+
+			if !ok {
+				log.Fatalf("HUD entity %d has the unknown text alignment %s\n", entityID, entityHud.Align)
+			}
+
+			g.ECSManager.GetComponentDataByName(entityID, "TEXT_COMPONENT").(*ecs.TextComponentData).Style.Align = align
+		}
+	}
+
+	// Show the values of the level's start right away
+	g.ECSManager.UpdateHud()
+}
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/ecs"
+)
+
+type levelUnlockCondition struct {
+	MinScore     int32    `json:"MinScore"`
+	RequiredKeys []string `json:"RequiredKeys"`
+}
+
+type LevelManifestEntry struct {
+	Name   string                `json:"Name"`
+	Path   string                `json:"Path"`
+	Unlock *levelUnlockCondition `json:"Unlock"`
+}
+
+// LevelManifestJSONConfig lists the levels in the order they are played
+type LevelManifestJSONConfig struct {
+	Levels []*LevelManifestEntry `json:"Levels"`
+}
+
+func LoadLevelManifest(Game *Game) {
+	manifest := &LevelManifestJSONConfig{}
+
+	data, readInErr := ioutil.ReadFile("./game/levels.json")
+
+	if readInErr != nil {
+		panic(readInErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, manifest)
+
+	if unmarshalErr != nil {
+		panic(unmarshalErr)
+	}
+
+	if len(manifest.Levels) == 0 {
+		panic("The level manifest does not list any levels")
+	}
+
+	Game.LevelManifest = manifest
+}
+
+// IsUnlocked reports whether the inventory collected so far is enough to play the level
+func (l *LevelManifestEntry) IsUnlocked(inventory *ecs.Inventory) bool {
+	if l.Unlock == nil {
+		return true
+	}
+
+	if inventory.Score < l.Unlock.MinScore {
+		return false
+	}
+
+	for _, keyName := range l.Unlock.RequiredKeys {
+		if !inventory.Keys[keyName] {
+			return false
+		}
+	}
+
+	return true
+}
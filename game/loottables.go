@@ -0,0 +1,59 @@
+package game
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/ecs"
+)
+
+type lootDrop struct {
+	// Asset to spawn, nothing is dropped if empty
+	Prefab string `json:"Prefab"`
+	Weight int    `json:"Weight"`
+}
+
+// LoadLootTables reads the loot tables breakable assets can refer to by name via "LootTable"
+func LoadLootTables(Game *Game) {
+	lootTables := make(map[string][]*lootDrop)
+
+	data, readInErr := ioutil.ReadFile("./game/loottables.json")
+
+	if readInErr != nil {
+		panic(readInErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, &lootTables)
+
+	if unmarshalErr != nil {
+		panic(unmarshalErr)
+	}
+
+	Game.LootTables = &lootTables
+}
+
+// loadLootTable hands a loot table to the ECSManager and loads the prefabs it can drop
+func loadLootTable(game *Game, name string) {
+	if _, alreadyLoaded := game.ECSManager.LootTables[name]; alreadyLoaded {
+		return
+	}
+
+	drops, ok := (*game.LootTables)[name]
+
+	if !ok {
+		log.Fatalf("Loot table %s does not exist\n", name)
+	}
+
+	lootTable := make([]ecs.LootDrop, 0, len(drops))
+
+	for _, drop := range drops {
+		if drop.Prefab != "" {
+			loadPrefab(game, drop.Prefab)
+		}
+
+		lootTable = append(lootTable, ecs.LootDrop{Prefab: drop.Prefab, Weight: drop.Weight})
+	}
+
+	game.ECSManager.LootTables[name] = lootTable
+}
@@ -3,34 +3,107 @@ package game
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/elliotchance/orderedmap"
+	"github.com/t-puetz/GoJumpAndRunAndShoot/behaviourtree"
 	"github.com/t-puetz/GoJumpAndRunAndShoot/ecs"
-	"github.com/veandco/go-sdl2/img"
 	"github.com/veandco/go-sdl2/sdl"
-	"github.com/veandco/go-sdl2/ttf"
 	_ "image/png"
 	"io/ioutil"
 	"log"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
 )
 
 type EntityJSONConfig struct {
-	Reference   string   `json:"Reference"`
-	Components  []uint16 `json:"Components"`
-	InitialPosX int32      `json:"InitialPosX"`
-	InitialPosY int32      `json:"InitialPosY"`
-	SpreadAlong string   `json:"SpreadAlong"`
+	Reference      string          `json:"Reference"`
+	Components     []uint16        `json:"Components"`
+	InitialPosX    int32           `json:"InitialPosX"`
+	InitialPosY    int32           `json:"InitialPosY"`
+	SpreadAlong    string          `json:"SpreadAlong"`
+	Movement       *movement       `json:"Movement"`
+	PassiveControl *passiveControl `json:"PassiveControl"`
+	BehaviourTree  string          `json:"BehaviourTree"`
+	Pickup         *pickup         `json:"Pickup"`
+	// Lets other entities link to this one by name instead of by ID
+	Name    string   `json:"Name"`
+	Door    *door    `json:"Door"`
+	Camera  *camera  `json:"Camera"`
+	Tilemap *tilemap `json:"Tilemap"`
+	// Places the entity relative to the logical viewport, InitialPosX and InitialPosY are added in pixels
+	Anchor *anchor `json:"Anchor"`
+	Hud    *hud    `json:"Hud"`
+	// One of "Background", "Terrain", "Actors", "Foreground" and "UI", see ecs.RenderLayerNames
+	Layer  string `json:"Layer"`
+	ZIndex int32  `json:"ZIndex"`
+}
+
+type passiveControl struct {
+	Behaviour      string `json:"Behaviour"`
+	Speed          int32  `json:"Speed"`
+	TurnAtLedges   bool   `json:"TurnAtLedges"`
+	JumpObstacles  bool   `json:"JumpObstacles"`
+	JumpSpeed      int32  `json:"JumpSpeed"`
+	StartDirection string `json:"StartDirection"`
+}
+
+type door struct {
+	KeyName string `json:"KeyName"`
+	// Entity IDs or names of the switches that open the door
+	Switches []string `json:"Switches"`
+}
+
+// anchor is a point of the logical viewport, (0, 0) is the top left and (1, 1) the bottom right corner
+type anchor struct {
+	X float64 `json:"X"`
+	Y float64 `json:"Y"`
+}
+
+type tilemapTile struct {
+	// Asset whose image is the tile
+	Asset string `json:"Asset"`
+	Solid bool   `json:"Solid"`
+}
+
+type tilemap struct {
+	TileWidth  int32 `json:"TileWidth"`
+	TileHeight int32 `json:"TileHeight"`
+	// Maps the characters used in Cells to tiles
+	Tileset map[string]*tilemapTile `json:"Tileset"`
+	// One string per row, one character per cell. Spaces and dots are empty cells.
+	Cells []string `json:"Cells"`
 }
 
 type LevelPhysics struct {
 	Gravity float64 `json:"Gravity"`
 }
 
+type LevelBounds struct {
+	MinX        int32 `json:"MinX"`
+	MaxX        int32 `json:"MaxX"`
+	MinY        int32 `json:"MinY"`
+	MaxY        int32 `json:"MaxY"`
+	DeathPlaneY int32 `json:"DeathPlaneY"`
+}
+
+type parallaxLayer struct {
+	Image         string  `json:"Image"`
+	ScrollFactorX float64 `json:"ScrollFactorX"`
+	ScrollFactorY float64 `json:"ScrollFactorY"`
+	RepeatX       bool    `json:"RepeatX"`
+	RepeatY       bool    `json:"RepeatY"`
+	OffsetY       int32   `json:"OffsetY"`
+}
+
 type LevelJSONConfig struct {
 	LevelPhysics         LevelPhysics                  `json:"LevelPhysics"`
+	LevelBounds          *LevelBounds                  `json:"LevelBounds"`
+	ParallaxLayers       []*parallaxLayer              `json:"ParallaxLayers"`
 	EntitiesDescriptions *map[string]*EntityJSONConfig `json:"Entities"`
+	// Path of the HUD description shown on top of the level, none if empty
+	Hud                         string `json:"Hud"`
 	EntitiesDescriptionsOrdered orderedmap.OrderedMap
 }
 
@@ -123,6 +196,10 @@ func LoadLvlConfig(Game *Game, path string) {
 
 	Game.LvlDescription = lvl
 	Game.LvlDescription.EntitiesDescriptionsOrdered = *(convertUnorderedToOrderedEntityDescriptionsMap(lvl.EntitiesDescriptions))
+
+	if lvl.Hud != "" {
+		addHudEntities(Game, lvl, lvl.Hud)
+	}
 }
 
 func (l *LevelJSONConfig) GetEntityDescription(entityID uint64) *EntityJSONConfig {
@@ -137,6 +214,21 @@ func (l *LevelJSONConfig) GetEntityDescription(entityID uint64) *EntityJSONConfi
 	return nil
 }
 
+// ResolveEntityLink returns the ID of the entity a link points to. A link is either an entity ID
+// or the Name of an entity, for a range of entities the first one of the range is used.
+func (l *LevelJSONConfig) ResolveEntityLink(link string) (uint64, bool) {
+	if entityID, err := strconv.ParseUint(link, 10, 64); err == nil {
+		return entityID, l.GetEntityDescription(entityID) != nil
+	}
+
+	for el := l.EntitiesDescriptionsOrdered.Front(); el != nil; el = el.Next() {
+		if el.Value.(*EntityJSONConfig).Name == link {
+			return el.Key.(uint64), true
+		}
+	}
+	return 0, false
+}
+
 func (l *LevelJSONConfig) GetFirstEntityIDFromRange(entityID uint64) int {
 	for entityIDStr, _ := range *l.EntitiesDescriptions {
 		var numKeysUpperLimit uint64
@@ -231,6 +323,14 @@ func connectAnimatedImageDataWithRenderAndAnimateComponentData(game *Game, entit
 
 			imageName = (*mainEntity.Animations)[animationType].Image
 
+			if (*mainEntity.Animations)[animationType].SpritesheetAvailable {
+				pACDCore := connectAtlasAnimation(game, entityIDStr, basePath, (*mainEntity.Animations)[animationType])
+				pACDCore.DefaultAnimationDuration = mainEntity.DefaultAnimationDuration
+				pACDCore.Show(pRCD, 0)
+				(*pACD.AnimationData)[animationType] = pACDCore
+				continue
+			}
+
 			if imageName == "" {
 				continue
 			}
@@ -252,24 +352,17 @@ func connectAnimatedImageDataWithRenderAndAnimateComponentData(game *Game, entit
 						imagesWhenRange = make([]*sdl.Surface, len(imagePathsWhenRange), len(imagePathsWhenRange))
 					}
 
-					pImage, err = img.Load(imagePathsWhenRange[i])
-
-					if err != nil {
-						log.Fatalf("Not able to create image for RenderComponent of Entity number %s\n", entityIDStr)
-					}
-
-					imagesWhenRange[i] = pImage
-
 					if i == 0 {
 						texturesWhenRange = make([]*sdl.Texture, len(imagePathsWhenRange), len(imagePathsWhenRange))
 					}
 
-					pTexture, err = game.Renderer.CreateTextureFromSurface(imagesWhenRange[i])
+					pImage, pTexture, err = game.ECSManager.Textures.Acquire(imagePathsWhenRange[i])
 
 					if err != nil {
-						log.Fatalf("Not able to create texture from surface for RenderComponent of Entity number %s\n", entityIDStr)
+						log.Fatalf("Not able to create image for RenderComponent of Entity number %s: %s\n", entityIDStr, err)
 					}
 
+					imagesWhenRange[i] = pImage
 					texturesWhenRange[i] = pTexture
 				}
 
@@ -281,16 +374,10 @@ func connectAnimatedImageDataWithRenderAndAnimateComponentData(game *Game, entit
 				pACDCore.Images = append(pACDCore.Images, imagesWhenRange...)
 				pACDCore.Textures = append(pACDCore.Textures, texturesWhenRange...)
 			} else {
-				pImage, err = img.Load(fullImagePath)
+				pImage, pTexture, err = game.ECSManager.Textures.Acquire(fullImagePath)
 
 				if err != nil {
-					log.Fatalf("Not able to create image for RenderComponent of Entity number %s\n", entityIDStr)
-				}
-
-				pTexture, err = game.Renderer.CreateTextureFromSurface(pImage)
-
-				if err != nil {
-					log.Fatalf("Not able to create texture from surface for RenderComponent of Entity number %s\n", entityIDStr)
+					log.Fatalf("Not able to create image for RenderComponent of Entity number %s: %s\n", entityIDStr, err)
 				}
 
 				pRCD.Path = fullImagePath
@@ -310,6 +397,48 @@ func connectAnimatedImageDataWithRenderAndAnimateComponentData(game *Game, entit
 	}
 }
 
+// connectAtlasAnimation loads the frames of an animation from the atlas named by its Spritesheet.
+// Every frame holds a reference to the atlas image, just like the images of other animations.
+func connectAtlasAnimation(game *Game, entityIDStr string, basePath string, anim *animation) *ecs.AnimationComponentDataCore {
+	atlas, err := ecs.LoadAtlas(basePath + anim.Spritesheet)
+
+	if err != nil {
+		log.Fatalf("Not able to load sprite atlas for Entity number %s: %s\n", entityIDStr, err)
+	}
+
+	frames := atlas.Frames
+
+	if anim.NumberAnimations > 0 && int(anim.NumberAnimations) < len(frames) {
+		frames = frames[:anim.NumberAnimations]
+	}
+
+	if len(frames) == 0 {
+		log.Fatalf("Sprite atlas %s of Entity number %s has no frames\n", basePath+anim.Spritesheet, entityIDStr)
+	}
+
+	pACDCore := &ecs.AnimationComponentDataCore{
+		NumberAnimations: uint8(len(frames)),
+		Paths:            make([]string, 0, len(frames)),
+		Images:           make([]*sdl.Surface, 0, len(frames)),
+		Textures:         make([]*sdl.Texture, 0, len(frames)),
+		Frames:           frames,
+	}
+
+	for range frames {
+		pImage, pTexture, err := game.ECSManager.Textures.Acquire(atlas.ImagePath)
+
+		if err != nil {
+			log.Fatalf("Not able to create image for RenderComponent of Entity number %s: %s\n", entityIDStr, err)
+		}
+
+		pACDCore.Paths = append(pACDCore.Paths, atlas.ImagePath)
+		pACDCore.Images = append(pACDCore.Images, pImage)
+		pACDCore.Textures = append(pACDCore.Textures, pTexture)
+	}
+
+	return pACDCore
+}
+
 func connectAnimatedImageDataWithRenderAndAnimateComponentUnwrapImageRange(fullImagePath string) ([]string, bool) {
 	var imagePathsWhenRange []string
 
@@ -380,16 +509,10 @@ func connectStillImageDataWithRenderAndAnimateComponentData(game *Game, entityID
 
 			err := errors.New("")
 
-			pImage, err = img.Load(fullImagePath)
-
-			if err != nil {
-				log.Fatalf("Not able to create image for RenderComponent of Entity number %s from path %s\n", entityIDStr, fullImagePath)
-			}
-
-			pTexture, err = game.Renderer.CreateTextureFromSurface(pImage)
+			pImage, pTexture, err = game.ECSManager.Textures.Acquire(fullImagePath)
 
 			if err != nil {
-				log.Fatalf("Not able to create texture from surface for RenderComponent of Entity number %s from path %s\n", entityIDStr, fullImagePath)
+				log.Fatalf("Not able to create image for RenderComponent of Entity number %s from path %s: %s\n", entityIDStr, fullImagePath, err)
 			}
 
 			pRCD.Path = fullImagePath
@@ -411,38 +534,127 @@ func connectTextDataWithRenderAndAnimateComponentData(game *Game, entityIDStr st
 		return
 	}
 
+	components, _ := entityManager.EntityToComponentMap.Get(uint64(entityID))
+
+	if !entityManager.HasNamedComponent(components.([]uint16), "TEXT_COMPONENT") {
+		return
+	}
+
 	mainEntity := (*assetDescriptions)[reference]
 
-	if mainEntity.Text != "" && mainEntity.FontSize > 0 {
-		// Text assets
+	if mainEntity.FontSize == 0 {
+		log.Fatalf("Entity number %s has a TextComponent but its asset %s has no FontSize\n", entityIDStr, reference)
+	}
+
+	style := ecs.TextStyle{
+		FontPath:  mainEntity.Font,
+		FontSize:  int(mainEntity.FontSize),
+		Color:     sdl.Color{R: 255, G: 0, B: 0, A: 255},
+		WrapWidth: mainEntity.WrapWidth,
+	}
 
-		var textToRender string
-		var font *ttf.Font
-		var text *sdl.Surface
-		var err error
+	if style.FontPath == "" {
+		style.FontPath = ecs.DEFAULT_FONT
+	}
 
-		textToRender = mainEntity.Text
+	if mainEntity.Color != nil {
+		style.Color = sdl.Color{R: mainEntity.Color.R, G: mainEntity.Color.G, B: mainEntity.Color.B, A: mainEntity.Color.A}
+	}
 
-		font, err = ttf.OpenFont("./assets/SourceCodePro-Bold.ttf", int(mainEntity.FontSize))
+	if mainEntity.Align != "" {
+		align, ok := ecs.TextAlignNames[mainEntity.Align]
 
-		if err != nil {
-			panic("Error creating SDL font.")
+		if !ok {
+			log.Fatalf("Asset %s has the unknown text alignment %s\n", reference, mainEntity.Align)
 		}
 
-		text, err = font.RenderUTF8Blended(textToRender, sdl.Color{R: 255, G: 0, B: 0, A: 255})
+		style.Align = align
+	}
 
-		if err != nil {
-			panic("Error creating SDL text.")
+	pTXCD := entityManager.GetComponentDataByName(uint64(entityID), "TEXT_COMPONENT").(*ecs.TextComponentData)
+	pTXCD.Content = mainEntity.Text
+	pTXCD.Style = style
+}
+
+// newWeapon creates a weapon from its asset description and loads its projectile
+func newWeapon(game *Game, entityWeapon *weapon) *ecs.Weapon {
+	if entityWeapon == nil {
+		return nil
+	}
+
+	loadPrefab(game, entityWeapon.Projectile)
+
+	return &ecs.Weapon{
+		Projectile: entityWeapon.Projectile,
+		Speed:      entityWeapon.Speed,
+		Lifetime:   entityWeapon.LifetimeMs,
+		FireRate:   entityWeapon.FireRateMs,
+		Damage:     entityWeapon.Damage,
+		UsesAmmo:   entityWeapon.UsesAmmo,
+	}
+}
+
+// loadPrefab loads the image of an asset so entities can be spawned from it while the game runs
+func loadPrefab(game *Game, reference string) {
+	if _, alreadyLoaded := game.ECSManager.Prefabs[reference]; alreadyLoaded {
+		return
+	}
+
+	asset, ok := (*game.AssetDescriptions)[reference]
+
+	if !ok {
+		log.Fatalf("Prefab %s has no asset description\n", reference)
+	}
+
+	fullImagePath := asset.ImagesBasePath + asset.Image
+
+	pImage, pTexture, err := game.ECSManager.Textures.Acquire(fullImagePath)
+
+	if err != nil {
+		log.Fatalf("Not able to create image for prefab %s from path %s: %s\n", reference, fullImagePath, err)
+	}
+
+	prefab := &ecs.Prefab{
+		Reference:  reference,
+		Components: asset.Components,
+		Path:       fullImagePath,
+		Image:      pImage,
+		Texture:    pTexture,
+	}
+
+	if asset.Pickup != nil {
+		prefab.Pickup = &ecs.PickupComponentData{Kind: asset.Pickup.Kind, Value: asset.Pickup.Value, KeyName: asset.Pickup.KeyName}
+	}
+
+	game.ECSManager.Prefabs[reference] = prefab
+}
+
+func RenderSystemSetInitialVals(g *Game) {
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "RENDER_COMPONENT") {
+			continue
 		}
 
-		textTexture, err := game.Renderer.CreateTextureFromSurface(text)
+		pRCD := g.ECSManager.GetComponentDataByName(entityID, "RENDER_COMPONENT").(*ecs.RenderComponentData)
+		entityJSONConfig := g.LvlDescription.GetEntityDescription(entityID)
 
-		if err != nil {
-			panic("Error creating text texture.")
+		pRCD.Layer = g.ECSManager.DefaultRenderLayer(components)
+		pRCD.ZIndex = entityJSONConfig.ZIndex
+
+		if entityJSONConfig.Layer == "" {
+			continue
+		}
+
+		layer, ok := ecs.RenderLayerNames[entityJSONConfig.Layer]
+
+		if !ok {
+			log.Fatalf("Entity number %d is on render layer %s which does not exist\n", entityID, entityJSONConfig.Layer)
 		}
 
-		pRCD.Text = text
-		pRCD.Texture = textTexture
+		pRCD.Layer = layer
 	}
 }
 
@@ -468,7 +680,12 @@ func TransformSystemSetInitialVals(g *Game) {
 			pRCD := g.ECSManager.GetComponentDataByName(entityID, "RENDER_COMPONENT").(*ecs.RenderComponentData)
 			firstEntity := lvlConfig.GetFirstEntityIDFromRange(entityID)
 			pTCD.PosY = entityJSONConfig.InitialPosY
-			pTCD.PosX = entityJSONConfig.InitialPosX + pRCD.Image.W*(int32(entityID)-int32(firstEntity))
+			w, _ := pRCD.Size()
+			pTCD.PosX = entityJSONConfig.InitialPosX + w*(int32(entityID)-int32(firstEntity))
+		} else if entityJSONConfig.Anchor != nil {
+			viewWidth, viewHeight := g.Display.LogicalSize()
+			pTCD.PosX = int32(math.Round(entityJSONConfig.Anchor.X*float64(viewWidth))) + entityJSONConfig.InitialPosX
+			pTCD.PosY = int32(math.Round(entityJSONConfig.Anchor.Y*float64(viewHeight))) + entityJSONConfig.InitialPosY
 		} else {
 			pTCD.PosX = entityJSONConfig.InitialPosX
 			pTCD.PosY = entityJSONConfig.InitialPosY
@@ -483,6 +700,478 @@ func TransformSystemSetInitialVals(g *Game) {
 	}
 }
 
+func ActiveControlSystemSetInitialVals(g *Game) {
+	// Without a movement description entities keep the old instant start/stop behaviour
+	defaultMovement := &movement{
+		GroundAcceleration: 5.0,
+		GroundDeceleration: 5.0,
+		AirAcceleration:    5.0,
+		AirDeceleration:    5.0,
+		MaxGroundSpeed:     5.0,
+		MaxAirSpeed:        5.0,
+		JumpSpeed:          31.0,
+		JumpReleaseSpeed:   31.0,
+	}
+
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "ACTIVE_CONTROL_COMPONENT") {
+			continue
+		}
+
+		pACD := g.ECSManager.GetComponentDataByName(entityID, "ACTIVE_CONTROL_COMPONENT").(*ecs.ActiveControlComponentData)
+		entityJSONConfig := g.LvlDescription.GetEntityDescription(entityID)
+
+		// The level's entity description overrides the asset's movement description
+		entityMovement := (*g.AssetDescriptions)[entityJSONConfig.Reference].Movement
+
+		if entityJSONConfig.Movement != nil {
+			entityMovement = entityJSONConfig.Movement
+		}
+
+		if entityMovement == nil {
+			entityMovement = defaultMovement
+		}
+
+		pACD.GroundAcceleration = entityMovement.GroundAcceleration
+		pACD.GroundDeceleration = entityMovement.GroundDeceleration
+		pACD.AirAcceleration = entityMovement.AirAcceleration
+		pACD.AirDeceleration = entityMovement.AirDeceleration
+		pACD.MaxGroundSpeed = entityMovement.MaxGroundSpeed
+		pACD.MaxAirSpeed = entityMovement.MaxAirSpeed
+		pACD.JumpSpeed = entityMovement.JumpSpeed
+		pACD.JumpReleaseSpeed = entityMovement.JumpReleaseSpeed
+		pACD.CoyoteTime = entityMovement.CoyoteTimeMs
+		pACD.JumpBufferTime = entityMovement.JumpBufferMs
+		pACD.CrouchSpeedFactor = entityMovement.CrouchSpeedFactor
+
+		if pACD.JumpSpeed == 0 {
+			pACD.JumpSpeed = defaultMovement.JumpSpeed
+		}
+
+		if pACD.JumpReleaseSpeed == 0 {
+			// No variable jump height
+			pACD.JumpReleaseSpeed = pACD.JumpSpeed
+		}
+
+		if pACD.CrouchSpeedFactor == 0 {
+			pACD.CrouchSpeedFactor = 1.0
+		}
+
+		pACD.Weapon = newWeapon(g, (*g.AssetDescriptions)[entityJSONConfig.Reference].Weapon)
+	}
+}
+
+func PassiveControlSystemSetInitialVals(g *Game) {
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "PASSIVE_CONTROL_COMPONENT_NPC") {
+			continue
+		}
+
+		pPCCD := g.ECSManager.GetComponentDataByName(entityID, "PASSIVE_CONTROL_COMPONENT_NPC").(*ecs.PassiveControlComponentData)
+		entityJSONConfig := g.LvlDescription.GetEntityDescription(entityID)
+		entityPassiveControl := entityJSONConfig.PassiveControl
+
+		if entityPassiveControl == nil {
+			pPCCD.Behaviour = ecs.BEHAVIOUR_IDLE
+			continue
+		}
+
+		pPCCD.Behaviour = entityPassiveControl.Behaviour
+		pPCCD.Speed = entityPassiveControl.Speed
+		pPCCD.TurnAtLedges = entityPassiveControl.TurnAtLedges
+		pPCCD.JumpObstacles = entityPassiveControl.JumpObstacles
+		pPCCD.JumpSpeed = entityPassiveControl.JumpSpeed
+		pPCCD.Direction = 1
+
+		if entityPassiveControl.StartDirection == "left" {
+			pPCCD.Direction = -1
+		}
+	}
+}
+
+func BehaviourSystemSetInitialVals(g *Game) {
+	leafFactory := ecs.NewBehaviourLeafFactory(g.ECSManager)
+
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "BEHAVIOUR_COMPONENT") {
+			continue
+		}
+
+		pBCD := g.ECSManager.GetComponentDataByName(entityID, "BEHAVIOUR_COMPONENT").(*ecs.BehaviourComponentData)
+		entityJSONConfig := g.LvlDescription.GetEntityDescription(entityID)
+
+		treeDescription, ok := (*g.BehaviourDescriptions)[entityJSONConfig.BehaviourTree]
+
+		if !ok {
+			log.Fatalf("Entity number %d uses unknown behaviour tree %q\n", entityID, entityJSONConfig.BehaviourTree)
+		}
+
+		// Every entity gets its own tree since nodes remember what they were doing
+		tree, err := behaviourtree.Build(treeDescription, leafFactory)
+
+		if err != nil {
+			log.Fatalf("Not able to build behaviour tree %s for entity number %d: %s\n", entityJSONConfig.BehaviourTree, entityID, err)
+		}
+
+		pBCD.Tree = tree
+		pBCD.Weapon = newWeapon(g, (*g.AssetDescriptions)[entityJSONConfig.Reference].Weapon)
+	}
+}
+
+func PickupSystemSetInitialVals(g *Game) {
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "PICKUP_COMPONENT") {
+			continue
+		}
+
+		pPUCD := g.ECSManager.GetComponentDataByName(entityID, "PICKUP_COMPONENT").(*ecs.PickupComponentData)
+		entityJSONConfig := g.LvlDescription.GetEntityDescription(entityID)
+
+		// The level's entity description overrides the asset's pickup description
+		entityPickup := (*g.AssetDescriptions)[entityJSONConfig.Reference].Pickup
+
+		if entityJSONConfig.Pickup != nil {
+			entityPickup = entityJSONConfig.Pickup
+		}
+
+		if entityPickup == nil {
+			log.Fatalf("Entity number %d has a PickupComponent but no Pickup description\n", entityID)
+		}
+
+		pPUCD.Kind = entityPickup.Kind
+		pPUCD.Value = entityPickup.Value
+		pPUCD.KeyName = entityPickup.KeyName
+	}
+}
+
+func CheckpointSystemSetInitialVals(g *Game) {
+	g.ECSManager.LevelBounds = nil
+	g.ECSManager.RespawnPoint = nil
+
+	if lvlBounds := g.LvlDescription.LevelBounds; lvlBounds != nil {
+		g.ECSManager.LevelBounds = &ecs.LevelBounds{
+			MinX:        lvlBounds.MinX,
+			MaxX:        lvlBounds.MaxX,
+			MinY:        lvlBounds.MinY,
+			MaxY:        lvlBounds.MaxY,
+			DeathPlaneY: lvlBounds.DeathPlaneY,
+		}
+	}
+
+	// Until the first checkpoint is touched the player respawns where the level starts
+	if playerID, hasPlayer := g.ECSManager.FindPlayer(); hasPlayer {
+		pTCD := g.ECSManager.GetComponentDataByName(playerID, "TRANSFORM_COMPONENT").(*ecs.TransformComponentData)
+		g.ECSManager.RespawnPoint = &ecs.RespawnPoint{PosX: pTCD.PosX, PosY: pTCD.PosY}
+	}
+}
+
+func DoorSystemSetInitialVals(g *Game) {
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "DOOR_COMPONENT") {
+			continue
+		}
+
+		pDCD := g.ECSManager.GetComponentDataByName(entityID, "DOOR_COMPONENT").(*ecs.DoorComponentData)
+		entityJSONConfig := g.LvlDescription.GetEntityDescription(entityID)
+
+		if entityJSONConfig.Door == nil {
+			log.Fatalf("Entity number %d has a DoorComponent but no Door description\n", entityID)
+		}
+
+		pDCD.KeyName = entityJSONConfig.Door.KeyName
+		pDCD.Switches = make([]uint64, 0, len(entityJSONConfig.Door.Switches))
+
+		for _, link := range entityJSONConfig.Door.Switches {
+			switchID, found := g.LvlDescription.ResolveEntityLink(link)
+
+			if !found {
+				log.Fatalf("Door %d is linked to switch %s which does not exist\n", entityID, link)
+			}
+
+			switchComponents, _ := g.ECSManager.EntityToComponentMap.Get(switchID)
+
+			if !g.ECSManager.HasNamedComponent(switchComponents.([]uint16), "SWITCH_COMPONENT") {
+				log.Fatalf("Door %d is linked to entity %d which is no switch\n", entityID, switchID)
+			}
+
+			pDCD.Switches = append(pDCD.Switches, switchID)
+		}
+	}
+}
+
+func BreakableSystemSetInitialVals(g *Game) {
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "BREAKABLE_COMPONENT") {
+			continue
+		}
+
+		entityJSONConfig := g.LvlDescription.GetEntityDescription(entityID)
+		entityBreakable := (*g.AssetDescriptions)[entityJSONConfig.Reference].Breakable
+
+		if entityBreakable == nil {
+			log.Fatalf("Entity number %d has a BreakableComponent but its asset %s has no Breakable description\n", entityID, entityJSONConfig.Reference)
+		}
+
+		pBRCD := g.ECSManager.GetComponentDataByName(entityID, "BREAKABLE_COMPONENT").(*ecs.BreakableComponentData)
+		pBRCD.HP = entityBreakable.HP
+		pBRCD.LootTable = entityBreakable.LootTable
+
+		if entityBreakable.LootTable != "" {
+			loadLootTable(g, entityBreakable.LootTable)
+		}
+	}
+}
+
+func CameraSystemSetInitialVals(g *Game) {
+	viewWidth, viewHeight := g.Display.LogicalSize()
+
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "CAMERA_COMPONENT") {
+			continue
+		}
+
+		entityJSONConfig := g.LvlDescription.GetEntityDescription(entityID)
+
+		// The level's entity description overrides the asset's camera description
+		entityCamera := (*g.AssetDescriptions)[entityJSONConfig.Reference].Camera
+
+		if entityJSONConfig.Camera != nil {
+			entityCamera = entityJSONConfig.Camera
+		}
+
+		if entityCamera == nil {
+			log.Fatalf("Entity number %d has a CameraComponent but no Camera description\n", entityID)
+		}
+
+		pCAMD := g.ECSManager.GetComponentDataByName(entityID, "CAMERA_COMPONENT").(*ecs.CameraComponentData)
+		pCAMD.DeadZoneWidth = entityCamera.DeadZoneWidth
+		pCAMD.DeadZoneHeight = entityCamera.DeadZoneHeight
+		pCAMD.LookAhead = entityCamera.LookAhead
+		pCAMD.Smoothing = entityCamera.Smoothing
+		pCAMD.ViewWidth = viewWidth
+		pCAMD.ViewHeight = viewHeight
+
+		if entityCamera.Target == "" {
+			playerID, hasPlayer := g.ECSManager.FindPlayer()
+
+			if !hasPlayer {
+				log.Fatalf("Camera %d has no target and there is no player to follow\n", entityID)
+			}
+
+			pCAMD.Target = playerID
+			continue
+		}
+
+		targetID, found := g.LvlDescription.ResolveEntityLink(entityCamera.Target)
+
+		if !found {
+			log.Fatalf("Camera %d should follow entity %s which does not exist\n", entityID, entityCamera.Target)
+		}
+
+		pCAMD.Target = targetID
+	}
+
+	// Start the level with the cameras where they belong instead of panning there
+	g.ECSManager.SnapCameras()
+}
+
+// loadParallaxLayers loads the images of the level's parallax layers
+func loadParallaxLayers(g *Game) {
+	g.ECSManager.ParallaxLayers = make([]*ecs.ParallaxLayer, 0, len(g.LvlDescription.ParallaxLayers))
+
+	for _, layer := range g.LvlDescription.ParallaxLayers {
+		pImage, pTexture, err := g.ECSManager.Textures.Acquire(layer.Image)
+
+		if err != nil {
+			log.Fatalf("Not able to create image for parallax layer from path %s: %s\n", layer.Image, err)
+		}
+
+		g.ECSManager.ParallaxLayers = append(g.ECSManager.ParallaxLayers, &ecs.ParallaxLayer{
+			Path:          layer.Image,
+			Image:         pImage,
+			Texture:       pTexture,
+			ScrollFactorX: layer.ScrollFactorX,
+			ScrollFactorY: layer.ScrollFactorY,
+			RepeatX:       layer.RepeatX,
+			RepeatY:       layer.RepeatY,
+			OffsetY:       layer.OffsetY,
+		})
+	}
+}
+
+func TilemapSetInitialVals(g *Game) {
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "TILEMAP_COMPONENT") {
+			continue
+		}
+
+		entityTilemap := g.LvlDescription.GetEntityDescription(entityID).Tilemap
+
+		if entityTilemap == nil {
+			log.Fatalf("Entity number %d has a TilemapComponent but no Tilemap description\n", entityID)
+		}
+
+		if entityTilemap.TileWidth <= 0 || entityTilemap.TileHeight <= 0 {
+			log.Fatalf("Tilemap %d needs a TileWidth and TileHeight\n", entityID)
+		}
+
+		pTMCD := g.ECSManager.GetComponentDataByName(entityID, "TILEMAP_COMPONENT").(*ecs.TilemapComponentData)
+		pTMCD.TileWidth = entityTilemap.TileWidth
+		pTMCD.TileHeight = entityTilemap.TileHeight
+
+		tileIndices := make(map[byte]int32)
+
+		for symbol, tileDescription := range entityTilemap.Tileset {
+			if len(symbol) != 1 || symbol == " " || symbol == "." {
+				log.Fatalf("Tilemap %d: %q cannot stand for a tile, use a single character other than space and dot\n", entityID, symbol)
+			}
+
+			asset, ok := (*g.AssetDescriptions)[tileDescription.Asset]
+
+			if !ok {
+				log.Fatalf("Tilemap %d: tile %s has no asset description\n", entityID, tileDescription.Asset)
+			}
+
+			fullImagePath := asset.ImagesBasePath + asset.Image
+			pImage, pTexture, err := g.ECSManager.Textures.Acquire(fullImagePath)
+
+			if err != nil {
+				log.Fatalf("Not able to create image for tile %s of tilemap %d from path %s: %s\n", tileDescription.Asset, entityID, fullImagePath, err)
+			}
+
+			tile := &ecs.Tile{Path: fullImagePath, Image: pImage, Texture: pTexture, Solid: tileDescription.Solid, Friction: 1.0}
+
+			if asset.Friction != nil {
+				tile.Friction = *asset.Friction
+			}
+
+			tileIndices[symbol[0]] = int32(len(pTMCD.Tileset))
+			pTMCD.Tileset = append(pTMCD.Tileset, tile)
+		}
+
+		columns, cells, err := tilemapCells(entityTilemap.Cells, tileIndices)
+
+		if err != nil {
+			log.Fatalf("Tilemap %d: %s\n", entityID, err)
+		}
+
+		pTMCD.Columns = columns
+		pTMCD.Rows = int32(len(entityTilemap.Cells))
+		pTMCD.Cells = cells
+	}
+}
+
+// tilemapCells turns the rows of a tilemap description into cells, row by row. The map is as wide
+// as its longest row, shorter rows are filled up with empty cells.
+func tilemapCells(rows []string, tileIndices map[byte]int32) (int32, []int32, error) {
+	var columns int32
+
+	for _, row := range rows {
+		if int32(len(row)) > columns {
+			columns = int32(len(row))
+		}
+	}
+
+	cells := make([]int32, int32(len(rows))*columns)
+
+	for row, symbols := range rows {
+		for column := int32(0); column < columns; column++ {
+			cell := int32(row)*columns + column
+
+			if column >= int32(len(symbols)) || symbols[column] == ' ' || symbols[column] == '.' {
+				cells[cell] = ecs.NO_TILE
+				continue
+			}
+
+			index, ok := tileIndices[symbols[column]]
+
+			if !ok {
+				return 0, nil, fmt.Errorf("%q in row %d is not in the tileset", symbols[column], row)
+			}
+
+			cells[cell] = index
+		}
+	}
+
+	return columns, cells, nil
+}
+
+func CollideSystemSetInitialVals(g *Game) {
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		if !g.ECSManager.HasNamedComponent(components, "COLLIDE_COMPONENT") {
+			continue
+		}
+
+		pCCD := g.ECSManager.GetComponentDataByName(entityID, "COLLIDE_COMPONENT").(*ecs.CollisionComponentData)
+		entityJSONConfig := g.LvlDescription.GetEntityDescription(entityID)
+
+		if friction := (*g.AssetDescriptions)[entityJSONConfig.Reference].Friction; friction != nil {
+			pCCD.Friction = *friction
+		}
+	}
+}
+
+func HealthSystemSetInitialVals(g *Game) {
+	for el := g.ECSManager.EntityToComponentMap.Front(); el != nil; el = el.Next() {
+		entityID := el.Key.(uint64)
+		components := el.Value.([]uint16)
+
+		entityJSONConfig := g.LvlDescription.GetEntityDescription(entityID)
+		asset := (*g.AssetDescriptions)[entityJSONConfig.Reference]
+
+		if g.ECSManager.HasNamedComponent(components, "DAMAGE_COMPONENT") {
+			pDCD := g.ECSManager.GetComponentDataByName(entityID, "DAMAGE_COMPONENT").(*ecs.DamageComponentData)
+			pDCD.Damage = asset.ContactDamage
+		}
+
+		if !g.ECSManager.HasNamedComponent(components, "HEALTH_COMPONENT") {
+			continue
+		}
+
+		if asset.Health == nil {
+			log.Fatalf("Entity number %d has a HealthComponent but its asset %s has no Health description\n", entityID, entityJSONConfig.Reference)
+		}
+
+		pHCD := g.ECSManager.GetComponentDataByName(entityID, "HEALTH_COMPONENT").(*ecs.HealthComponentData)
+		pHCD.MaxHP = asset.Health.MaxHP
+		pHCD.HP = asset.Health.MaxHP
+		pHCD.InvulnerabilityTime = asset.Health.InvulnerabilityMs
+		pHCD.HurtTime = asset.Health.HurtMs
+		pHCD.BlinkInterval = asset.Health.BlinkIntervalMs
+		pHCD.KnockbackX = asset.Health.KnockbackX
+		pHCD.KnockbackY = asset.Health.KnockbackY
+	}
+}
+
+// InitializeLevel replaces the entity map without freeing anything,
+// ClearEntities has to release the images of the previous level before
 func InitializeLevel(g *Game) {
 	entityComponentMap := CreateEntityComponent(g.LvlDescription)
 	g.ECSManager.EntityToComponentMap = nil
@@ -490,5 +1179,22 @@ func InitializeLevel(g *Game) {
 	CreateLvlsEntityAndComponents(g, entityComponentMap)
 	g.ECSManager.LinkComponentsWithProperDataStruct()
 	LoadImagesAndTextures(g)
+	TilemapSetInitialVals(g)
+	loadParallaxLayers(g)
+	RenderSystemSetInitialVals(g)
 	TransformSystemSetInitialVals(g)
+	ActiveControlSystemSetInitialVals(g)
+	PassiveControlSystemSetInitialVals(g)
+	BehaviourSystemSetInitialVals(g)
+	CollideSystemSetInitialVals(g)
+	HealthSystemSetInitialVals(g)
+	HudSystemSetInitialVals(g)
+	PickupSystemSetInitialVals(g)
+	CheckpointSystemSetInitialVals(g)
+	DoorSystemSetInitialVals(g)
+	BreakableSystemSetInitialVals(g)
+	CameraSystemSetInitialVals(g)
+
+	// Texts are drawn right away, the TextSystem does not run on the menu screens
+	g.ECSManager.RefreshTexts()
 }
\ No newline at end of file
@@ -0,0 +1,47 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/t-puetz/GoJumpAndRunAndShoot/ecs"
+)
+
+func TestTilemapCells(t *testing.T) {
+	const none = ecs.NO_TILE
+
+	tileIndices := map[byte]int32{'G': 0, 'S': 1}
+
+	tests := []struct {
+		name    string
+		rows    []string
+		columns int32
+		cells   []int32
+	}{
+		{"full rows", []string{"GG", "SG"}, 2, []int32{0, 0, 1, 0}},
+		{"spaces and dots are empty", []string{" .S", "G G"}, 3, []int32{none, none, 1, 0, none, 0}},
+		{"ragged rows", []string{"G", "GSG", ""}, 3, []int32{0, none, none, 0, 1, 0, none, none, none}},
+		{"trailing empty cells", []string{"G  ", "S"}, 3, []int32{0, none, none, 1, none, none}},
+		{"no rows", nil, 0, []int32{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			columns, cells, err := tilemapCells(test.rows, tileIndices)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if columns != test.columns || !reflect.DeepEqual(cells, test.cells) {
+				t.Errorf("tilemapCells() = %d columns %v, want %d columns %v", columns, cells, test.columns, test.cells)
+			}
+		})
+	}
+}
+
+func TestTilemapCellsUnknownTile(t *testing.T) {
+	if _, _, err := tilemapCells([]string{"GG", "GX"}, map[byte]int32{'G': 0}); err == nil {
+		t.Error("tilemapCells() with a symbol missing from the tileset did not fail")
+	}
+}
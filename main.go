@@ -1,18 +1,38 @@
 package main
 
 import (
+	"flag"
 	"github.com/t-puetz/GoJumpAndRunAndShoot/game"
 	"github.com/t-puetz/GoJumpAndRunAndShoot/input"
+	"github.com/t-puetz/GoJumpAndRunAndShoot/statemachine"
 	_ "image/png"
 )
 
 func main() {
-	g := &game.Game{}
+	headless := flag.Bool("headless", false, "draw to an image in memory instead of a window")
+	frames := flag.Int("frames", 0, "skip the menu, play this many ticks of the first level and exit")
+	screenshot := flag.String("screenshot", "", "with -frames, save the last frame to this PNG file")
+	debugDraw := flag.Bool("debug", false, "start with the debug overlay shown, F3 toggles it")
+	logCollisions := flag.Bool("log-collisions", false, "log every collision that is detected and resolved")
+	flag.Parse()
+
+	g := &game.Game{Headless: *headless}
 
 	g.Keyboard = input.NewKeyboard()
 	g.InitializeSDL()
 	g.PrepareBasicGameData()
 
+	g.ECSManager.DebugDraw = *debugDraw
+	g.ECSManager.LogCollisions = *logCollisions
+
+	if *frames > 0 {
+		g.StateMachine.DoTransition(statemachine.WELCOME_SCREEN, statemachine.GAME)
+		g.LoadFirstLevel()
+		g.RunFrames(*frames, *screenshot)
+		g.Shutdown()
+		return
+	}
+
 	g.LoadWelcomeScreen()
 	g.RunWelcomeScreen()
 
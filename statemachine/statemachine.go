@@ -1,9 +1,7 @@
 package statemachine
 
 import (
-	"github.com/veandco/go-sdl2/sdl"
 	"log"
-	"os"
 )
 
 type State uint8
@@ -66,9 +64,10 @@ func NewStateMachine() *StateMachine {
 	sm.Transitions[PAUSE][2] = OPTIONS_MENU
 	sm.Transitions[PAUSE][3] = WELCOME_SCREEN
 
-	sm.Transitions[GAME_OVER] = make([]State, 2, 2)
+	sm.Transitions[GAME_OVER] = make([]State, 3, 3)
 	sm.Transitions[GAME_OVER][0] = EXIT
 	sm.Transitions[GAME_OVER][1] = WELCOME_SCREEN
+	sm.Transitions[GAME_OVER][2] = GAME
 
 	return sm
 }
@@ -83,10 +82,9 @@ func (sm *StateMachine) DoTransition(from, to State) bool {
 
 				switch stateCase {
 				case "WELCOME_SCREEN:EXIT":
+					// The game shuts itself down when it sees the EXIT state
 					log.Println(stateCase)
 					sm.CurrentState = toState
-					sdl.Quit()
-					os.Exit(0)
 					return true
 				case "WELCOME_SCREEN:GAME":
 					log.Println(stateCase)
@@ -96,6 +94,14 @@ func (sm *StateMachine) DoTransition(from, to State) bool {
 					log.Println(stateCase)
 					sm.CurrentState = toState
 					return true
+				case "GAME:WELCOME_SCREEN":
+					log.Println(stateCase)
+					sm.CurrentState = toState
+					return true
+				case "GAME:GAME_OVER":
+					log.Println(stateCase)
+					sm.CurrentState = toState
+					return true
 				case "GAME:PAUSE":
 					log.Println(stateCase)
 					sm.CurrentState = toState
@@ -104,6 +110,18 @@ func (sm *StateMachine) DoTransition(from, to State) bool {
 					log.Println(stateCase)
 					sm.CurrentState = toState
 					return true
+				case "GAME_OVER:EXIT":
+					log.Println(stateCase)
+					sm.CurrentState = toState
+					return true
+				case "GAME_OVER:GAME":
+					log.Println(stateCase)
+					sm.CurrentState = toState
+					return true
+				case "GAME_OVER:WELCOME_SCREEN":
+					log.Println(stateCase)
+					sm.CurrentState = toState
+					return true
 				}
 			}
 		}